@@ -0,0 +1,109 @@
+package gop1
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultReconnectMinDelay = 1000
+	defaultReconnectMaxDelay = 60000
+)
+
+// ErrDeviceGone is reported when the serial device disappeared, which is what
+// happens to USB serial devices when they are unplugged or re-enumerated
+var ErrDeviceGone = errors.New("serial device disappeared")
+
+// serialByIDDir is where udev maintains stable symlinks to serial devices
+const serialByIDDir = "/dev/serial/by-id"
+
+// ConnectionState is the state of the connection to the serial device
+type ConnectionState int
+
+// These are the states the connection to the serial device can be in
+const (
+	// StateConnected means the serial device was (re)opened
+	StateConnected ConnectionState = iota
+	// StateDisconnected means reading from the serial device failed and it
+	// was closed
+	StateDisconnected
+	// StateReconnecting means P1 is about to reopen the serial device
+	StateReconnecting
+)
+
+// String returns a human readable representation of the connection state
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateReconnecting:
+		return "reconnecting"
+	default:
+		return "unknown"
+	}
+}
+
+// ConnectionEvent describes a change in the connection to the serial device
+type ConnectionEvent struct {
+	State   ConnectionState
+	Device  string
+	Attempt int   // number of the reconnect attempt, when reconnecting
+	Err     error // reason of the disconnect or failed reconnect attempt
+}
+
+// resolveByID returns the stable symlink in given directory, usually
+// /dev/serial/by-id, pointing to given device or the device itself when there
+// is none
+func resolveByID(dir string, device string) string {
+	if filepath.Dir(device) == dir {
+		return device
+	}
+
+	target, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return device
+	}
+
+	links, err := os.ReadDir(dir)
+	if err != nil {
+		return device
+	}
+
+	for _, link := range links {
+		path := filepath.Join(dir, link.Name())
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && resolved == target {
+			return path
+		}
+	}
+
+	return device
+}
+
+// deviceGone returns whether given device no longer exists
+func deviceGone(device string) bool {
+	if device == "" {
+		return false
+	}
+
+	_, err := os.Stat(device)
+
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// sleepContext waits for given duration or until given context is cancelled
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
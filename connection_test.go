@@ -0,0 +1,44 @@
+package gop1
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveByID(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	byID := filepath.Join(dir, "by-id")
+	require.NoError(t, os.Mkdir(byID, 0o755))
+
+	device := filepath.Join(dir, "ttyUSB0")
+	require.NoError(t, os.WriteFile(device, nil, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ttyUSB1"), nil, 0o600))
+
+	link := filepath.Join(byID, "usb-FTDI_FT232R_USB_UART_A10KBS9Y-if00-port0")
+	require.NoError(t, os.Symlink("../ttyUSB0", link))
+
+	assert.Equal(t, link, resolveByID(byID, device))
+	assert.Equal(t, link, resolveByID(byID, link))
+	assert.Equal(t, filepath.Join(dir, "ttyUSB1"), resolveByID(byID, filepath.Join(dir, "ttyUSB1")))
+	assert.Equal(t, filepath.Join(dir, "ttyUSB2"), resolveByID(byID, filepath.Join(dir, "ttyUSB2")))
+	assert.Equal(t, device, resolveByID(filepath.Join(dir, "missing"), device))
+}
+
+func TestDeviceGone(t *testing.T) {
+	t.Parallel()
+
+	device := filepath.Join(t.TempDir(), "ttyUSB0")
+	require.NoError(t, os.WriteFile(device, nil, 0o600))
+
+	assert.False(t, deviceGone(device))
+	assert.False(t, deviceGone(""))
+
+	require.NoError(t, os.Remove(device))
+	assert.True(t, deviceGone(device))
+}
@@ -0,0 +1,84 @@
+package gop1
+
+import (
+	"strconv"
+)
+
+const (
+	crc16Polynomial = 0xA001
+	crcLength       = 4
+)
+
+// CRCStatus is the outcome of validating the CRC of a telegram
+type CRCStatus int
+
+// These are the possible outcomes of validating the CRC of a telegram
+const (
+	// CRCNotPresent means the telegram didn't carry a CRC, which is the case
+	// for DSMR 2.2 and 3.0 meters
+	CRCNotPresent CRCStatus = iota
+	// CRCValid means the CRC sent by the meter matches the telegram
+	CRCValid
+	// CRCInvalid means the CRC sent by the meter didn't match the telegram,
+	// which usually indicates line noise
+	CRCInvalid
+)
+
+// String returns a human readable representation of the CRC status
+func (s CRCStatus) String() string {
+	switch s {
+	case CRCNotPresent:
+		return "not present"
+	case CRCValid:
+		return "valid"
+	case CRCInvalid:
+		return "invalid"
+	default:
+		return "unknown"
+	}
+}
+
+// crc16 calculates the CRC16 checksum DSMR 4+ and e-MUCS meters use, which is
+// CRC-16/ARC: polynomial 0xA001 (reversed 0x8005) without initial value or
+// final XOR
+func crc16(data []byte) uint16 {
+	var crc uint16
+
+	for _, b := range data {
+		crc ^= uint16(b)
+
+		for range 8 {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ crc16Polynomial
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// checkCRC validates the given telegram, which should span from the / header
+// up to and including the ! delimiter, against the hexadecimal checksum the
+// meter sent along with it
+func checkCRC(telegram string, checksum string) CRCStatus {
+	if checksum == "" {
+		return CRCNotPresent
+	}
+
+	if len(checksum) != crcLength {
+		return CRCInvalid
+	}
+
+	expected, err := strconv.ParseUint(checksum, 16, 16)
+	if err != nil {
+		return CRCInvalid
+	}
+
+	if crc16([]byte(telegram)) != uint16(expected) {
+		return CRCInvalid
+	}
+
+	return CRCValid
+}
@@ -0,0 +1,35 @@
+package gop1
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC16(t *testing.T) {
+	t.Parallel()
+
+	// check value of CRC-16/ARC
+	assert.Equal(t, uint16(0xBB3D), crc16([]byte("123456789")))
+	assert.Equal(t, uint16(0), crc16(nil))
+}
+
+func TestCheckCRC(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	// split the fixture in the telegram and its checksum
+	telegram := string(fixture[:len(fixture)-6])
+	checksum := string(fixture[len(fixture)-6 : len(fixture)-2])
+
+	assert.Equal(t, CRCValid, checkCRC(telegram, checksum))
+	assert.Equal(t, CRCInvalid, checkCRC(telegram[1:], checksum))
+	assert.Equal(t, CRCInvalid, checkCRC(telegram, "0000"))
+	assert.Equal(t, CRCInvalid, checkCRC(telegram, "ZZZZ"))
+	assert.Equal(t, CRCInvalid, checkCRC(telegram, "E47"))
+	assert.Equal(t, CRCNotPresent, checkCRC(telegram, ""))
+}
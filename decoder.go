@@ -0,0 +1,39 @@
+package gop1
+
+import (
+	"io"
+	"time"
+)
+
+// Decoder reads and parses telegrams from an input stream, such as a serial
+// device, a network connection or a capture file
+type Decoder struct {
+	framer   *Framer
+	registry *OBISRegistry
+}
+
+// NewDecoder returns a Decoder reading from given reader
+func NewDecoder(reader io.Reader) *Decoder {
+	return &Decoder{
+		framer:   NewFramer(reader, 0),
+		registry: defaultRegistry,
+	}
+}
+
+// Decode reads the next complete telegram from the input and parses it. It
+// returns io.EOF when the input is exhausted. Like ParseTelegram, it returns
+// both the telegram and an error when some of its lines could not be parsed.
+// Errors reading the input are returned without a telegram, after which Decode
+// can be called again to continue with the next telegram. The telegram's
+// Received time is the time it was decoded.
+func (d *Decoder) Decode() (*Telegram, error) {
+	frame, err := d.framer.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	tgram, err := d.registry.parseFrame(frame)
+	tgram.Received = time.Now()
+
+	return tgram, err
+}
@@ -0,0 +1,47 @@
+package gop1
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	valid, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	invalid, err := os.ReadFile("testdata/crc/invalid")
+	require.NoError(t, err)
+
+	other, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	decoder := NewDecoder(bytes.NewReader(bytes.Join([][]byte{valid, invalid, other}, nil)))
+
+	tgram, err := decoder.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, `ISk5\2MT382-1000`, tgram.Device)
+	assert.Len(t, tgram.Objects, 35)
+	assert.Equal(t, CRCValid, tgram.CRCStatus)
+	assert.Equal(t, "E47C", tgram.Checksum)
+	assert.False(t, tgram.Received.IsZero())
+
+	tgram, err = decoder.Decode()
+	require.NoError(t, err)
+	assert.Len(t, tgram.Objects, 35)
+	assert.Equal(t, CRCInvalid, tgram.CRCStatus)
+
+	tgram, err = decoder.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, `FLU5\493523491_A`, tgram.Device)
+	assert.Len(t, tgram.Objects, 24)
+
+	_, err = decoder.Decode()
+	require.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,71 @@
+package gop1
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// defaultDetectTimeout allows for DSMR 2.2 up to 4 meters, which only send a
+// telegram every 10 seconds
+const defaultDetectTimeout = 11000
+
+// ErrSerialNotDetected is returned when none of the known serial line settings
+// yielded a telegram
+var ErrSerialNotDetected = errors.New("could not detect serial line settings")
+
+// detectSerialSettings are the serial line settings probed when auto-detecting,
+// in order of preference
+var detectSerialSettings = []SerialSettings{DSMR4Serial, DSMR2Serial}
+
+// detectSerial probes the known serial line settings by opening the serial
+// device with each of them until a plausible telegram is read
+func detectSerial(config P1Config, open func(SerialSettings) (io.ReadCloser, error)) (SerialSettings, error) {
+	timeout := time.Millisecond * time.Duration(config.DetectTimeout)
+	if timeout <= 0 {
+		timeout = time.Millisecond * defaultDetectTimeout
+	}
+
+	for _, settings := range detectSerialSettings {
+		serialDevice, err := open(settings)
+		if err != nil {
+			return SerialSettings{}, err
+		}
+
+		ok := probeSerial(serialDevice, config.MaxTelegramSize, time.Now().Add(timeout))
+		if err := serialDevice.Close(); err != nil {
+			return SerialSettings{}, err
+		}
+
+		if ok {
+			return settings, nil
+		}
+	}
+
+	return SerialSettings{}, ErrSerialNotDetected
+}
+
+// probeSerial returns whether a plausible telegram, with a header and at least
+// one valid OBIS line, is read from given serial device before the deadline
+func probeSerial(serialDevice io.Reader, maxSize int, deadline time.Time) bool {
+	framer := NewFramer(serialDevice, maxSize)
+
+	for time.Now().Before(deadline) {
+		frame, err := framer.Next()
+		if err != nil {
+			// the serial device reports read timeouts as io.EOF
+			if errors.Is(err, io.EOF) || errors.Is(err, ErrTelegramTooLarge) {
+				continue
+			}
+
+			return false
+		}
+
+		tgram, _ := defaultRegistry.parseFrame(frame)
+		if tgram.Device != "" && len(tgram.Objects) > 0 && tgram.CRCStatus != CRCInvalid {
+			return true
+		}
+	}
+
+	return false
+}
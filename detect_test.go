@@ -0,0 +1,73 @@
+package gop1
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectSerial(t *testing.T) {
+	t.Parallel()
+
+	valid, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	// what a DSMR 2.2 meter looks like at the wrong baud rate
+	garbage := bytes.Repeat([]byte("\x00\xf8\x80/x\xfe\r\n\x00!"), 64)
+
+	tests := []struct {
+		name     string
+		inputs   map[SerialSettings][]byte
+		settings SerialSettings
+		err      error
+	}{
+		{
+			name:     "dsmr4",
+			inputs:   map[SerialSettings][]byte{DSMR4Serial: valid, DSMR2Serial: garbage},
+			settings: DSMR4Serial,
+		},
+		{
+			name:     "dsmr2",
+			inputs:   map[SerialSettings][]byte{DSMR4Serial: garbage, DSMR2Serial: valid},
+			settings: DSMR2Serial,
+		},
+		{
+			name:   "none",
+			inputs: map[SerialSettings][]byte{DSMR4Serial: garbage, DSMR2Serial: garbage},
+			err:    ErrSerialNotDetected,
+		},
+		{
+			name:   "missing",
+			inputs: map[SerialSettings][]byte{},
+			err:    os.ErrNotExist,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			settings, err := detectSerial(P1Config{DetectTimeout: 50}, func(settings SerialSettings) (io.ReadCloser, error) {
+				input, ok := test.inputs[settings]
+				if !ok {
+					return nil, os.ErrNotExist
+				}
+
+				return io.NopCloser(bytes.NewReader(input)), nil
+			})
+
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.settings, settings)
+		})
+	}
+}
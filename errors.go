@@ -0,0 +1,34 @@
+package gop1
+
+import (
+	"fmt"
+)
+
+// ReadError is reported when reading from the serial device failed, for
+// instance because the USB device was unplugged
+type ReadError struct {
+	Err error
+}
+
+// Error implements the error interface
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("error reading from serial device: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// CRCError is reported for telegrams of which the CRC sent by the meter didn't
+// match the calculated CRC
+type CRCError struct {
+	Device     string
+	Checksum   string // as sent by the meter
+	Calculated uint16
+}
+
+// Error implements the error interface
+func (e *CRCError) Error() string {
+	return fmt.Sprintf("CRC mismatch in telegram of %s: got %s, calculated %04X", e.Device, e.Checksum, e.Calculated)
+}
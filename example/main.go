@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	// the alpine image has no time zone database, which meter timestamps are
+	// parsed with
+	_ "time/tzdata"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -96,8 +103,13 @@ func main() {
 		return
 	}
 
-	// start reading from P1 port
-	p1.Start()
+	defer p1.Close()
+
+	// start reading from P1 port until interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	p1.Start(ctx)
 
 	for tgram := range p1.Incoming {
 		for _, obj := range tgram.Objects {
@@ -149,4 +161,9 @@ func main() {
 			}
 		}
 	}
+
+	// p1.Incoming was closed, find out why
+	if err := p1.Err(); err != nil && !errors.Is(err, context.Canceled) {
+		log.WithError(err).WithField("deviceName", *deviceName).Error("stopped reading from serial device")
+	}
 }
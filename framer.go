@@ -0,0 +1,149 @@
+package gop1
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	defaultMaxTelegramSize = 8192
+	maxChecksumLineLength  = 16
+)
+
+// ErrTelegramTooLarge is returned by Framer when a telegram exceeds the maximum
+// telegram size before its end was found
+var ErrTelegramTooLarge = errors.New("telegram exceeds maximum size")
+
+// Frame is a single raw telegram as read by Framer
+type Frame struct {
+	// Data holds the telegram from the / header up to and including the !
+	// delimiter, which is exactly what the CRC is calculated over
+	Data []byte
+	// Checksum is the hexadecimal CRC sent after the ! delimiter, which is
+	// empty for DSMR 2.2 and 3.0 meters
+	Checksum string
+}
+
+// CRCStatus validates the frame's data against its checksum
+func (f *Frame) CRCStatus() CRCStatus {
+	return checkCRC(string(f.Data), f.Checksum)
+}
+
+// Bytes returns the raw telegram including the line with the checksum
+func (f *Frame) Bytes() []byte {
+	data := make([]byte, 0, len(f.Data)+len(f.Checksum)+2)
+	data = append(data, f.Data...)
+	data = append(data, f.Checksum...)
+
+	return append(data, '\r', '\n')
+}
+
+// Framer reads complete telegrams from an io.Reader. A telegram only starts at
+// a / at the beginning of a line and ends at the ! delimiter plus the line with
+// the CRC following it. Anything in between telegrams, such as the remainder of
+// a telegram that was already being sent when the reader was opened, is
+// dropped.
+type Framer struct {
+	reader     *bufio.Reader
+	maxSize    int
+	frame      []byte
+	checksum   []byte
+	inChecksum bool
+	lastByte   byte
+	// consumed is the number of bytes of the input processed so far, which
+	// is the offset of the end of a telegram when it is returned
+	consumed int64
+}
+
+// NewFramer returns a Framer reading from given reader. Telegrams larger than
+// maxSize bytes are discarded, when maxSize is 0 or less a default is used
+func NewFramer(reader io.Reader, maxSize int) *Framer {
+	if maxSize <= 0 {
+		maxSize = defaultMaxTelegramSize
+	}
+
+	return &Framer{
+		reader:  bufio.NewReader(reader),
+		maxSize: maxSize,
+		// the start of the stream counts as the start of a line
+		lastByte: '\n',
+	}
+}
+
+// Next returns the next complete telegram. When the underlying reader returns
+// an error, Next returns that error but retains the partial telegram, so a
+// subsequent call continues where it left off. When a telegram exceeds the
+// maximum size, it is dropped and ErrTelegramTooLarge is returned.
+func (f *Framer) Next() (*Frame, error) {
+	for {
+		b, err := f.reader.ReadByte()
+		if err != nil {
+			// the CRC line of the last telegram might not be terminated
+			if f.inChecksum && errors.Is(err, io.EOF) {
+				return f.finish(), nil
+			}
+
+			return nil, err
+		}
+
+		f.consumed++
+		lineStart := f.lastByte == '\n'
+		f.lastByte = b
+
+		switch {
+		case f.inChecksum:
+			// a new header means the CRC line was not terminated properly
+			if b == headerDelimiter && lineStart {
+				_ = f.reader.UnreadByte()
+				f.consumed--
+				f.lastByte = '\n'
+
+				return f.finish(), nil
+			}
+
+			if b == '\n' || len(f.checksum) >= maxChecksumLineLength {
+				return f.finish(), nil
+			}
+
+			f.checksum = append(f.checksum, b)
+		case b == headerDelimiter && lineStart:
+			// (re)start the telegram at every header, since a header within a
+			// telegram means the previous telegram was never completed
+			f.frame = append(f.frame[:0], b)
+		case f.frame == nil:
+			// drop everything up to the first header
+		default:
+			f.frame = append(f.frame, b)
+			if b == crcDelimiter {
+				f.inChecksum = true
+
+				continue
+			}
+
+			if len(f.frame) > f.maxSize {
+				f.reset()
+
+				return nil, ErrTelegramTooLarge
+			}
+		}
+	}
+}
+
+func (f *Framer) finish() *Frame {
+	frame := &Frame{
+		Data:     f.frame,
+		Checksum: strings.TrimSpace(string(f.checksum)),
+	}
+
+	f.reset()
+
+	return frame
+}
+
+func (f *Framer) reset() {
+	f.frame = nil
+	f.checksum = nil
+	f.inChecksum = false
+}
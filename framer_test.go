@@ -0,0 +1,194 @@
+package gop1
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eofReader returns io.EOF once after every chunk, like a serial device does
+// when its read timeout expires
+type eofReader struct {
+	chunks [][]byte
+	eof    bool
+}
+
+func (r *eofReader) Read(p []byte) (int, error) {
+	if r.eof || len(r.chunks) == 0 {
+		r.eof = false
+
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	r.eof = true
+
+	return n, nil
+}
+
+func readFrames(t *testing.T, framer *Framer) ([]*Frame, []error) {
+	t.Helper()
+
+	var (
+		frames []*Frame
+		errs   []error
+	)
+
+	for {
+		frame, err := framer.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return frames, errs
+			}
+
+			errs = append(errs, err)
+
+			continue
+		}
+
+		frames = append(frames, frame)
+	}
+}
+
+func TestFramer(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		input     string
+		maxSize   int
+		frames    int
+		checksums []string
+		errors    int
+	}{
+		{
+			name:      "single",
+			input:     string(fixture),
+			frames:    1,
+			checksums: []string{"E47C"},
+		},
+		{
+			name:      "partial_leading",
+			input:     "0-1:24.2.1(101209112500W)(12785.123*m3)\r\n!EF2F\r\n" + string(fixture),
+			frames:    1,
+			checksums: []string{"E47C"},
+		},
+		{
+			name:      "garbage_leading",
+			input:     "\x00\xff/garbage(\r\n" + string(fixture) + string(fixture),
+			frames:    2,
+			checksums: []string{"E47C", "E47C"},
+		},
+		{
+			name:      "incomplete_before_header",
+			input:     "/ISk5\\2MT382-1000\r\n\r\n1-3:0.2.8(50)\r\n" + string(fixture),
+			frames:    1,
+			checksums: []string{"E47C"},
+		},
+		{
+			name:      "no_crc",
+			input:     "/KMP5 KA6U001585575011\r\n\r\n0-0:96.1.1(204B413655303031353835353735303131)\r\n!\r\n",
+			frames:    1,
+			checksums: []string{""},
+		},
+		{
+			name:      "no_crc_no_newline",
+			input:     "/KMP5 KA6U001585575011\r\n\r\n0-0:96.1.1(204B413655303031353835353735303131)\r\n!",
+			frames:    1,
+			checksums: []string{""},
+		},
+		{
+			name:      "unterminated_crc",
+			input:     strings.TrimSpace(string(fixture)),
+			frames:    1,
+			checksums: []string{"E47C"},
+		},
+		{
+			name:      "truncated",
+			input:     string(fixture[:100]),
+			frames:    0,
+			checksums: []string{},
+		},
+		{
+			name:      "too_large",
+			input:     string(fixture) + "/" + strings.Repeat("x", 4096) + "\r\n!0000\r\n" + string(fixture),
+			maxSize:   len(fixture),
+			frames:    2,
+			checksums: []string{"E47C", "E47C"},
+			errors:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			frames, errs := readFrames(t, NewFramer(strings.NewReader(test.input), test.maxSize))
+			require.Len(t, frames, test.frames)
+			assert.Len(t, errs, test.errors)
+
+			for i, frame := range frames {
+				assert.Equal(t, byte(headerDelimiter), frame.Data[0])
+				assert.Equal(t, byte(crcDelimiter), frame.Data[len(frame.Data)-1])
+				assert.Equal(t, test.checksums[i], frame.Checksum)
+			}
+
+			for _, err := range errs {
+				require.ErrorIs(t, err, ErrTelegramTooLarge)
+			}
+		})
+	}
+}
+
+func TestFramerResume(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	// split the telegram over several reads, each followed by io.EOF
+	reader := &eofReader{}
+	for chunk := range chunks(fixture, 64) {
+		reader.chunks = append(reader.chunks, chunk)
+	}
+
+	framer := NewFramer(reader, 0)
+
+	var frame *Frame
+	for range len(reader.chunks) * 2 {
+		frame, err = framer.Next()
+		if err == nil {
+			break
+		}
+
+		require.ErrorIs(t, err, io.EOF)
+	}
+
+	require.NotNil(t, frame)
+	assert.Equal(t, fixture[:bytes.IndexByte(fixture, crcDelimiter)+1], frame.Data)
+	assert.Equal(t, CRCValid, frame.CRCStatus())
+	assert.Equal(t, fixture, frame.Bytes())
+}
+
+func chunks(data []byte, size int) func(func([]byte) bool) {
+	return func(yield func([]byte) bool) {
+		for len(data) > 0 {
+			n := min(size, len(data))
+			if !yield(data[:n]) {
+				return
+			}
+
+			data = data[n:]
+		}
+	}
+}
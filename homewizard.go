@@ -0,0 +1,191 @@
+package gop1
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollInterval = 1000
+	// requests time out after this many poll intervals by default
+	defaultTimeoutIntervals = 5
+	homeWizardPath          = "/api/v1/telegram"
+)
+
+// HomeWizardTransport is the Transport for a HomeWizard Wi-Fi P1 meter, which
+// offers the raw telegram through its local API. The API is polled at the
+// configured interval and telegrams that were already seen, going by their
+// timestamp, are skipped.
+type HomeWizardTransport struct {
+	// Address is the host name or IP address of the meter, or the base URL of
+	// its API
+	Address  string
+	Interval int // in milliseconds
+	// Timeout is the time after which a request to the API is given up,
+	// defaults to five intervals
+	Timeout int // in milliseconds
+	// Client is used to make requests, defaults to http.DefaultClient
+	Client *http.Client
+	// Registry is used to find the timestamp of telegrams, defaults to the
+	// global registry
+	Registry *OBISRegistry
+
+	mu            sync.Mutex
+	lastTimestamp string
+}
+
+// NewHomeWizard returns a P1 object reading telegrams from the HomeWizard Wi-Fi
+// P1 meter on given address, using given HTTP client or http.DefaultClient when
+// nil. Polling is resumed whenever the API fails.
+func NewHomeWizard(address string, client *http.Client, config P1Config) (*P1, error) {
+	config.Reconnect = true
+
+	return NewFromTransport(&HomeWizardTransport{
+		Address:  address,
+		Client:   client,
+		Registry: config.Registry,
+	}, config)
+}
+
+// Open starts polling the API. Polling stops when the returned reader is
+// closed or the API fails, in which case reading returns the error.
+func (t *HomeWizardTransport) Open(context.Context) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, writer := io.Pipe()
+
+	go func() {
+		writer.CloseWithError(t.poll(ctx, writer))
+	}()
+
+	return &pipeReader{PipeReader: reader, cancel: cancel}, nil
+}
+
+// Name returns the URL of the API
+func (t *HomeWizardTransport) Name() string {
+	return t.url()
+}
+
+func (t *HomeWizardTransport) url() string {
+	address := strings.TrimSuffix(t.Address, "/")
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	return address + homeWizardPath
+}
+
+// poll fetches the telegram from the API at every interval and writes new ones
+// to given writer
+func (t *HomeWizardTransport) poll(ctx context.Context, writer io.Writer) error {
+	interval := t.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(time.Millisecond * time.Duration(interval))
+	defer ticker.Stop()
+
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeoutIntervals * interval
+	}
+
+	for {
+		data, err := t.fetch(ctx, time.Millisecond*time.Duration(timeout))
+		if err != nil {
+			return err
+		}
+
+		if t.isNew(data) {
+			// make sure the CRC line is terminated before the next telegram
+			if !strings.HasSuffix(string(data), "\n") {
+				data = append(data, '\r', '\n')
+			}
+
+			if _, err := writer.Write(data); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// fetch requests the telegram from the API, giving up after given timeout so a
+// meter that stops responding is noticed
+func (t *HomeWizardTransport) fetch(ctx context.Context, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from %s: %s", t.url(), resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// isNew returns whether given telegram has a different timestamp than the
+// previous one. Telegrams without timestamp are always considered new.
+func (t *HomeWizardTransport) isNew(data []byte) bool {
+	registry := t.Registry
+	if registry == nil {
+		registry = defaultRegistry
+	}
+
+	tgram, _ := registry.ParseTelegram(data)
+	if tgram == nil {
+		// let P1 deal with whatever this is
+		return true
+	}
+
+	var timestamp string
+
+	for _, obj := range tgram.Objects {
+		if obj.Type == OBISTypeDateTimestamp && len(obj.Values) > 0 {
+			timestamp = obj.Values[0].Value
+
+			break
+		}
+	}
+
+	if timestamp == "" {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if timestamp == t.lastTimestamp {
+		return false
+	}
+
+	t.lastTimestamp = timestamp
+
+	return true
+}
@@ -0,0 +1,164 @@
+package gop1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHomeWizard(t *testing.T) {
+	t.Parallel()
+
+	// the responses of the API, including duplicates and a failure
+	responses := []string{
+		timestampedTelegram(t, "101209113020W"),
+		timestampedTelegram(t, "101209113020W"),
+		timestampedTelegram(t, "101209113021W"),
+		"",
+		timestampedTelegram(t, "101209113021W"),
+		strings.TrimSpace(timestampedTelegram(t, "101209113022W")),
+		timestampedTelegram(t, "101209113022W"),
+	}
+
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/telegram", r.URL.Path)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		response := responses[min(requests, len(responses)-1)]
+		requests++
+
+		if response == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	var errs []error
+
+	p1, err := NewFromTransport(&HomeWizardTransport{Address: server.URL, Interval: 1, Timeout: 1000}, P1Config{
+		Reconnect:         true,
+		ReconnectMinDelay: 1,
+		OnError: func(err error) {
+			errs = append(errs, err)
+		},
+	})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	var timestamps []string
+
+	for tgram := range p1.Incoming {
+		for _, obj := range tgram.Objects {
+			if obj.Type == OBISTypeDateTimestamp {
+				timestamps = append(timestamps, obj.Values[0].Value)
+			}
+		}
+
+		if len(timestamps) == 3 {
+			require.NoError(t, p1.Close())
+		}
+	}
+
+	assert.Equal(t, []string{"101209113020W", "101209113021W", "101209113022W"}, timestamps)
+
+	// the failing API was reported
+	var readErrs []error
+
+	for _, err := range errs {
+		var readErr *ReadError
+		if errors.As(err, &readErr) {
+			readErrs = append(readErrs, readErr)
+		}
+	}
+
+	require.Len(t, readErrs, 1)
+	require.ErrorContains(t, readErrs[0], "503 Service Unavailable")
+}
+
+func TestHomeWizardURL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "http://192.168.1.10/api/v1/telegram", (&HomeWizardTransport{Address: "192.168.1.10"}).Name())
+	assert.Equal(t, "https://p1meter.local/api/v1/telegram", (&HomeWizardTransport{Address: "https://p1meter.local/"}).Name())
+}
+
+func TestHomeWizardTimeout(t *testing.T) {
+	t.Parallel()
+
+	// a meter accepting requests without ever answering them
+	hang := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(hang)
+
+	readErrs := make(chan error, 1)
+
+	p1, err := NewFromTransport(&HomeWizardTransport{Address: server.URL, Interval: 1, Timeout: 20}, P1Config{
+		OnError: func(err error) {
+			readErrs <- err
+		},
+	})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	for range p1.Incoming {
+		t.Fatal("no telegrams expected")
+	}
+
+	require.ErrorIs(t, <-readErrs, context.DeadlineExceeded)
+	require.ErrorIs(t, p1.Err(), context.DeadlineExceeded)
+}
+
+func TestHomeWizardRegistry(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(fixture)
+	}))
+	defer server.Close()
+
+	// without a timestamp, every telegram the API returns is new
+	registry := NewOBISRegistry()
+	require.NoError(t, registry.Register("0-0:1.0.0", "Clock"))
+
+	p1, err := NewHomeWizard(server.URL, server.Client(), P1Config{Registry: registry})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	telegrams := 0
+	for range p1.Incoming {
+		telegrams++
+		if telegrams == 2 {
+			require.NoError(t, p1.Close())
+		}
+	}
+
+	assert.Equal(t, 2, telegrams)
+}
@@ -0,0 +1,164 @@
+package gop1
+
+import (
+	"slices"
+	"strconv"
+)
+
+// These are the OBIS codes of the objects describing M-Bus devices, on any
+// channel
+var (
+	mbusDeviceTypeCode          = MustParseOBIS("0-*:24.1.0")
+	mbusEquipmentIdentifierCode = MustParseOBIS("0-*:96.1.0")
+	mbusEquipmentIdentifierAlt  = MustParseOBIS("0-*:96.1.1")
+	mbusValveStateCode          = MustParseOBIS("0-*:24.4.0")
+	mbusReadingCode             = MustParseOBIS("0-*:24.2.1")
+	mbusReadingAltCode          = MustParseOBIS("0-*:24.2.3")
+)
+
+// MBusDeviceType is the type of an M-Bus device, as reported by the meter
+type MBusDeviceType int
+
+// These are the M-Bus device types found in telegrams
+const (
+	MBusDeviceElectricity MBusDeviceType = 2
+	MBusDeviceGas         MBusDeviceType = 3
+	MBusDeviceHeat        MBusDeviceType = 4
+	MBusDeviceWarmWater   MBusDeviceType = 6
+	MBusDeviceWater       MBusDeviceType = 7
+)
+
+// mbusReadings are the types and units of the readings of known M-Bus device
+// types
+var mbusReadings = map[MBusDeviceType]struct {
+	obisType OBISType
+	unit     string
+}{
+	MBusDeviceElectricity: {OBISTypeSlaveElectricityDelivered, "kWh"},
+	MBusDeviceGas:         {OBISTypeGasDelivered, "m3"},
+	MBusDeviceHeat:        {OBISTypeHeatDelivered, "GJ"},
+	MBusDeviceWarmWater:   {OBISTypeWarmWaterDelivered, "m3"},
+	MBusDeviceWater:       {OBISTypeWaterDelivered, "m3"},
+}
+
+// String returns the name of the device type
+func (t MBusDeviceType) String() string {
+	switch t {
+	case MBusDeviceElectricity:
+		return "electricity"
+	case MBusDeviceGas:
+		return "gas"
+	case MBusDeviceHeat:
+		return "heat"
+	case MBusDeviceWarmWater:
+		return "warm water"
+	case MBusDeviceWater:
+		return "water"
+	default:
+		return "unknown (" + strconv.Itoa(int(t)) + ")"
+	}
+}
+
+// MBusDevice is a device connected to the meter over M-Bus, such as a gas or
+// water meter
+type MBusDevice struct {
+	Channel     int
+	DeviceType  MBusDeviceType
+	EquipmentID string
+	// ValveState is empty when the device doesn't report a valve
+	ValveState string
+	// Reading is the latest reading of the device, with the time it was taken
+	// as first value and the reading as second value. It is nil when the
+	// device didn't report a reading.
+	Reading *TelegramObject
+}
+
+// Channel returns the M-Bus channel of the object, 1 to 4 for objects of
+// devices like gas and water meters or 0 for objects of the meter itself
+func (o *TelegramObject) Channel() int {
+	if o.Code.A != 0 || o.Code.B < 0 {
+		return 0
+	}
+
+	return o.Code.B
+}
+
+// MBusDevices returns the M-Bus devices described by the telegram, ordered by
+// their channel
+func (t *Telegram) MBusDevices() []*MBusDevice {
+	var devices []*MBusDevice
+
+	for _, obj := range t.Objects {
+		channel := obj.Channel()
+		if channel == 0 || len(obj.Values) == 0 {
+			continue
+		}
+
+		i := slices.IndexFunc(devices, func(d *MBusDevice) bool { return d.Channel == channel })
+		if i < 0 {
+			devices = append(devices, &MBusDevice{Channel: channel})
+			i = len(devices) - 1
+		}
+
+		device := devices[i]
+
+		switch code := obj.Code.AnyChannel(); code {
+		case mbusDeviceTypeCode:
+			device.DeviceType = parseMBusDeviceType(obj)
+		case mbusEquipmentIdentifierCode, mbusEquipmentIdentifierAlt:
+			device.EquipmentID = obj.Values[0].Value
+		case mbusValveStateCode:
+			device.ValveState = obj.Values[0].Value
+		case mbusReadingCode, mbusReadingAltCode:
+			// readings are listed oldest first
+			device.Reading = obj
+		}
+	}
+
+	slices.SortFunc(devices, func(a, b *MBusDevice) int { return a.Channel - b.Channel })
+
+	return devices
+}
+
+// classifyMBus assigns the readings of M-Bus devices the type and unit matching
+// their device type, as the meter reports them all with the same OBIS codes
+func classifyMBus(tgram *Telegram) {
+	deviceTypes := make(map[int]MBusDeviceType)
+
+	for _, obj := range tgram.Objects {
+		if obj.Code.AnyChannel() == mbusDeviceTypeCode && obj.Channel() > 0 {
+			deviceTypes[obj.Channel()] = parseMBusDeviceType(obj)
+		}
+	}
+
+	for _, obj := range tgram.Objects {
+		reading, ok := mbusReadings[deviceTypes[obj.Channel()]]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case obj.Type == OBISTypeGasDelivered:
+			obj.Type = reading.obisType
+
+			// the reading is the last value, after the time it was taken
+			if last := len(obj.Values) - 1; last >= 0 && obj.Values[last].Unit == "" {
+				obj.Values[last].Unit = reading.unit
+			}
+		case obj.Type == OBISTypeGasEquipmentIdentifier && reading.obisType != OBISTypeGasDelivered:
+			obj.Type = OBISTypeMBusEquipmentIdentifier
+		}
+	}
+}
+
+// parseMBusDeviceType returns the device type of given device type object,
+// which is 0 when it can't be parsed
+func parseMBusDeviceType(obj *TelegramObject) MBusDeviceType {
+	if len(obj.Values) == 0 {
+		return 0
+	}
+
+	deviceType, _ := strconv.Atoi(obj.Values[0].Value)
+
+	return MBusDeviceType(deviceType)
+}
@@ -0,0 +1,160 @@
+package gop1
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMBusDevices(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file    string
+		devices []MBusDevice
+	}{
+		{
+			file: "testdata/parser/output0",
+			devices: []MBusDevice{
+				{Channel: 1, DeviceType: 3, EquipmentID: "3232323241424344313233343536373839"},
+			},
+		},
+		{
+			file: "testdata/parser/output1",
+			devices: []MBusDevice{
+				{Channel: 1, DeviceType: 3, EquipmentID: "3232323241424344313233343536373839", ValveState: "1"},
+			},
+		},
+		{
+			// a gas meter and a water meter
+			file: "testdata/parser/output2",
+			devices: []MBusDevice{
+				{Channel: 1, DeviceType: 3, EquipmentID: "37464C4F32313139303333373333", ValveState: "1"},
+				{Channel: 2, DeviceType: 7, EquipmentID: "3853414731323334353637383930"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			t.Parallel()
+
+			fixture, err := os.ReadFile(test.file)
+			require.NoError(t, err)
+
+			tgram, err := ParseTelegram(fixture)
+			require.NoError(t, err)
+
+			devices := tgram.MBusDevices()
+			require.Len(t, devices, len(test.devices))
+
+			for i, device := range devices {
+				require.NotNil(t, device.Reading)
+				assert.Equal(t, device.Channel, device.Reading.Channel())
+				assert.Len(t, device.Reading.Values, 2)
+
+				device.Reading = nil
+				assert.Equal(t, test.devices[i], *device)
+			}
+		})
+	}
+}
+
+func TestTelegramObjectChannel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code    string
+		channel int
+	}{
+		{"0-1:24.2.1", 1},
+		{"0-4:24.2.1", 4},
+		{"0-0:96.1.1", 0},
+		{"1-0:1.8.1", 0},
+		{"0-*:24.2.1", 0},
+	}
+
+	for _, test := range tests {
+		obj := &TelegramObject{Code: MustParseOBIS(test.code)}
+		assert.Equal(t, test.channel, obj.Channel(), test.code)
+	}
+}
+
+func TestMBusDevicesEmpty(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, (&Telegram{}).MBusDevices())
+}
+
+func TestClassifyMBus(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/parser/output2")
+	require.NoError(t, err)
+
+	tgram, err := ParseTelegram(fixture)
+	require.NoError(t, err)
+
+	devices := tgram.MBusDevices()
+	require.Len(t, devices, 2)
+
+	assert.Equal(t, MBusDeviceGas, devices[0].DeviceType)
+	assert.Equal(t, OBISType(OBISTypeGasDelivered), devices[0].Reading.Type)
+	assert.Equal(t, TelegramValue{"00112.384", "m3"}, devices[0].Reading.Values[1])
+
+	// the water meter on the same bus isn't mistaken for gas
+	assert.Equal(t, MBusDeviceWater, devices[1].DeviceType)
+	assert.Equal(t, OBISType(OBISTypeWaterDelivered), devices[1].Reading.Type)
+	assert.Equal(t, TelegramValue{"00872.234", "m3"}, devices[1].Reading.Values[1])
+	assert.Equal(t, 1, countType(tgram, OBISTypeGasEquipmentIdentifier))
+	assert.Equal(t, 1, countType(tgram, OBISTypeMBusEquipmentIdentifier))
+
+	// without being mistaken for the equipment identifier of the meter itself
+	assert.Equal(t, 1, countType(tgram, OBISTypeEquipmentIdentifier))
+	assert.Equal(t, 0, tgram.Object(OBISTypeEquipmentIdentifier).Channel())
+}
+
+func TestClassifyMBusDeviceTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		deviceType string
+		reading    string
+		obisType   OBISType
+		unit       string
+	}{
+		{"002", "(000123.456*kWh)", OBISTypeSlaveElectricityDelivered, "kWh"},
+		{"003", "(00112.384*m3)", OBISTypeGasDelivered, "m3"},
+		{"004", "(00012.345*GJ)", OBISTypeHeatDelivered, "GJ"},
+		{"004", "(00012.345)", OBISTypeHeatDelivered, "GJ"},
+		{"006", "(00001.234*m3)", OBISTypeWarmWaterDelivered, "m3"},
+		{"007", "(00872.234*m3)", OBISTypeWaterDelivered, "m3"},
+		{"007", "(00872.234)", OBISTypeWaterDelivered, "m3"},
+		// unknown device types are left alone
+		{"099", "(00001.234)", OBISTypeGasDelivered, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.deviceType+test.reading, func(t *testing.T) {
+			t.Parallel()
+
+			// the device type can follow the reading as well
+			tgram, err := ParseTelegram([]byte("/ISk5\\2MT382-1000\r\n\r\n" +
+				"0-3:24.2.1(200512134558S)" + test.reading + "\r\n" +
+				"0-3:24.1.0(" + test.deviceType + ")\r\n!\r\n"))
+			require.NoError(t, err)
+
+			assert.Equal(t, test.obisType, tgram.Objects[0].Type)
+			assert.Equal(t, test.unit, tgram.Objects[0].Values[1].Unit)
+		})
+	}
+}
+
+func TestMBusDeviceTypeString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "water", MBusDeviceWater.String())
+	assert.Equal(t, "unknown (99)", MBusDeviceType(99).String())
+}
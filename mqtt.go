@@ -0,0 +1,199 @@
+package gop1
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultMQTTKeepAlive = 30000
+	// mqttClientIDPrefix is followed by a random suffix, since brokers
+	// disconnect a client when another one connects with the same ID
+	mqttClientIDPrefix   = "gop1-"
+	mqttClientIDSuffix   = 16 // keeps the ID within the 23 bytes every broker allows
+	mqttProtocolVersion  = 4  // MQTT 3.1.1
+	mqttSubscribeFailure = 0x80
+)
+
+var (
+	// ErrMQTTConnectionRefused is returned when the MQTT broker refused the
+	// connection, for instance because of invalid credentials
+	ErrMQTTConnectionRefused = errors.New("MQTT connection refused")
+	// ErrMQTTSubscriptionRefused is returned when the MQTT broker refused the
+	// subscription to the topic
+	ErrMQTTSubscriptionRefused = errors.New("MQTT subscription refused")
+	// ErrMQTTConnectionLost is returned when the connection to the MQTT broker
+	// was lost
+	ErrMQTTConnectionLost = errors.New("MQTT connection lost")
+)
+
+// MQTTTransport is the Transport for telegrams published to an MQTT topic, like
+// P1 dongles running Tasmota or ESPHome do. The payloads of the messages are
+// concatenated, so telegrams split over several messages are reassembled.
+type MQTTTransport struct {
+	// Address is the host:port of the broker, or its URL like
+	// ssl://broker:8883 or ws://broker:80/mqtt
+	Address string
+	Topic   string
+	QoS     byte // to subscribe with
+	// ClientID defaults to a unique ID for every connection
+	ClientID string
+	Username string
+	Password string
+	// TLSConfig is used for ssl:// and wss:// brokers
+	TLSConfig   *tls.Config
+	DialTimeout int // in milliseconds
+	KeepAlive   int // in milliseconds
+	// LinePerMessage should be set when every line of a telegram is published
+	// as a separate message without line ending
+	LinePerMessage bool
+}
+
+// NewMQTT returns a P1 object reading telegrams published to given topic on the
+// MQTT broker on given address. The connection is reestablished whenever it is
+// lost.
+func NewMQTT(address string, topic string, config P1Config) (*P1, error) {
+	config.Reconnect = true
+
+	return NewFromTransport(&MQTTTransport{Address: address, Topic: topic}, config)
+}
+
+// Open connects to the broker and subscribes to the topic
+func (t *MQTTTransport) Open(ctx context.Context) (io.ReadCloser, error) {
+	reader, writer := io.Pipe()
+
+	client := paho.NewClient(t.clientOptions(writer))
+
+	token := client.Connect()
+	if err := waitToken(ctx, token); err != nil {
+		client.Disconnect(0)
+
+		if connect, ok := token.(*paho.ConnectToken); ok && connect.ReturnCode() != 0 {
+			return nil, fmt.Errorf("%w: %w", ErrMQTTConnectionRefused, err)
+		}
+
+		return nil, err
+	}
+
+	token = client.Subscribe(t.Topic, t.QoS, func(_ paho.Client, msg paho.Message) {
+		// fails once the reader is closed, after which the client is
+		// disconnected
+		_, _ = writer.Write(terminateMessage(msg.Payload(), t.LinePerMessage))
+	})
+	if err := waitToken(ctx, token); err != nil {
+		client.Disconnect(0)
+
+		return nil, err
+	}
+
+	if subscribe, ok := token.(*paho.SubscribeToken); ok && subscribe.Result()[t.Topic] == mqttSubscribeFailure {
+		client.Disconnect(0)
+
+		return nil, fmt.Errorf("%w: %s", ErrMQTTSubscriptionRefused, t.Topic)
+	}
+
+	return &mqttReader{PipeReader: reader, client: client}, nil
+}
+
+// Name returns the address of the broker and the topic
+func (t *MQTTTransport) Name() string {
+	return t.broker() + "/" + t.Topic
+}
+
+func (t *MQTTTransport) broker() string {
+	if strings.Contains(t.Address, "://") {
+		return strings.TrimSuffix(t.Address, "/")
+	}
+
+	return "tcp://" + t.Address
+}
+
+// clientOptions returns the options for a client writing to given writer.
+// Reconnecting is left to P1, so a lost connection closes the writer.
+func (t *MQTTTransport) clientOptions(writer *io.PipeWriter) *paho.ClientOptions {
+	clientID := t.ClientID
+	if clientID == "" {
+		clientID = mqttClientIDPrefix + rand.Text()[:mqttClientIDSuffix]
+	}
+
+	dialTimeout := t.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = defaultDialTimeout
+	}
+
+	keepAlive := t.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = defaultMQTTKeepAlive
+	}
+
+	return paho.NewClientOptions().
+		AddBroker(t.broker()).
+		SetClientID(clientID).
+		SetUsername(t.Username).
+		SetPassword(t.Password).
+		SetTLSConfig(t.TLSConfig).
+		SetProtocolVersion(mqttProtocolVersion).
+		SetCleanSession(true).
+		SetConnectTimeout(time.Millisecond * time.Duration(dialTimeout)).
+		SetKeepAlive(time.Millisecond * time.Duration(keepAlive)).
+		SetAutoReconnect(false).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			// not wrapping err, as a lost connection is no end of input
+			writer.CloseWithError(fmt.Errorf("%w: %v", ErrMQTTConnectionLost, err)) //nolint:errorlint // see above
+		})
+}
+
+// mqttReader returns the payloads of the messages published to the subscribed
+// topic
+type mqttReader struct {
+	*io.PipeReader
+	client paho.Client
+}
+
+// Close disconnects from the broker
+func (r *mqttReader) Close() error {
+	// closing the pipe first unblocks a message being written
+	err := r.PipeReader.Close()
+	r.client.Disconnect(0)
+
+	return err
+}
+
+// waitToken waits for given token to complete or given context to be done
+func waitToken(ctx context.Context, token paho.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// terminateMessage adds a line ending to given message when it's needed to
+// keep the telegram intact once messages are concatenated: at the end of every
+// message with a single line per message, otherwise only after a CRC
+func terminateMessage(msg []byte, linePerMessage bool) []byte {
+	if len(msg) == 0 || msg[len(msg)-1] == '\n' {
+		return msg
+	}
+
+	// a telegram ends at the delimiter, optionally followed by a complete CRC
+	if !linePerMessage {
+		i := bytes.LastIndexByte(msg, crcDelimiter)
+		if i < 0 || (len(msg)-i-1 != 0 && len(msg)-i-1 != crcLength) {
+			return msg
+		}
+	}
+
+	// don't write past the message into a buffer that isn't ours
+	return append(msg[:len(msg):len(msg)], '\r', '\n')
+}
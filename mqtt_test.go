@@ -0,0 +1,195 @@
+package gop1
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startBroker starts an embedded MQTT broker accepting user meter with password
+// secret, which is denied access to the topic dsmr/denied
+func startBroker(t *testing.T) (*mochi.Server, string) {
+	t.Helper()
+
+	broker := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	require.NoError(t, broker.AddHook(new(auth.Hook), &auth.Options{
+		Ledger: &auth.Ledger{
+			Auth: auth.AuthRules{{Username: "meter", Password: "secret", Allow: true}},
+			ACL:  auth.ACLRules{{Filters: auth.Filters{"dsmr/denied": auth.Deny}}},
+		},
+	}))
+
+	listener := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	require.NoError(t, broker.AddListener(listener))
+	require.NoError(t, broker.Serve())
+
+	t.Cleanup(func() {
+		_ = broker.Close()
+	})
+
+	return broker, listener.Address()
+}
+
+func TestMQTT(t *testing.T) {
+	t.Parallel()
+
+	output0, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	output1, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	broker, address := startBroker(t)
+
+	for _, qos := range []byte{0, 1} {
+		p1, err := NewFromTransport(&MQTTTransport{
+			Address:  address,
+			Topic:    "dsmr/raw",
+			QoS:      qos,
+			ClientID: "meter",
+			Username: "meter",
+			Password: "secret",
+		}, P1Config{})
+		require.NoError(t, err)
+		p1.Start(t.Context())
+
+		// the first telegram is split over several messages, the second one
+		// is published without trailing line ending
+		for _, msg := range [][]byte{output0[:10], output0[10:500], output0[500:], bytes.TrimSpace(output1)} {
+			require.NoError(t, broker.Publish("dsmr/raw", msg, false, qos))
+		}
+
+		telegram := <-p1.Incoming
+		assert.Equal(t, `ISk5\2MT382-1000`, telegram.Device)
+		assert.Equal(t, CRCValid, telegram.CRCStatus)
+		assert.Len(t, telegram.Objects, 35)
+
+		telegram = <-p1.Incoming
+		assert.Equal(t, `FLU5\493523491_A`, telegram.Device)
+		assert.Len(t, telegram.Objects, 24)
+
+		require.NoError(t, p1.Close())
+	}
+}
+
+func TestMQTTRefused(t *testing.T) {
+	t.Parallel()
+
+	_, address := startBroker(t)
+
+	_, err := NewFromTransport(&MQTTTransport{
+		Address:  address,
+		Topic:    "dsmr/raw",
+		Username: "meter",
+		Password: "wrong",
+	}, P1Config{})
+	require.ErrorIs(t, err, ErrMQTTConnectionRefused)
+
+	_, err = NewFromTransport(&MQTTTransport{
+		Address:  address,
+		Topic:    "dsmr/denied",
+		Username: "meter",
+		Password: "secret",
+	}, P1Config{})
+	require.ErrorIs(t, err, ErrMQTTSubscriptionRefused)
+}
+
+func TestMQTTConnectionLost(t *testing.T) {
+	t.Parallel()
+
+	broker, address := startBroker(t)
+
+	var errs []error
+
+	p1, err := NewFromTransport(&MQTTTransport{
+		Address:  address,
+		Topic:    "dsmr/raw",
+		ClientID: "lost",
+		Username: "meter",
+		Password: "secret",
+	}, P1Config{
+		OnError: func(err error) {
+			errs = append(errs, err)
+		},
+	})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	// disconnect the client from the broker side
+	client, ok := broker.Clients.Get("lost")
+	require.True(t, ok)
+	client.Stop(io.ErrUnexpectedEOF)
+
+	for range p1.Incoming {
+		t.Fatal("no telegrams expected")
+	}
+
+	var readErr *ReadError
+	require.ErrorAs(t, p1.Err(), &readErr)
+	require.ErrorIs(t, p1.Err(), ErrMQTTConnectionLost)
+	require.Len(t, errs, 1)
+}
+
+func TestMQTTClientID(t *testing.T) {
+	t.Parallel()
+
+	transport := &MQTTTransport{Address: "broker:1883", Topic: "dsmr/raw"}
+	writer := new(io.PipeWriter)
+
+	// readers on the same broker don't take over each other's session
+	first := transport.clientOptions(writer).ClientID
+	second := transport.clientOptions(writer).ClientID
+	assert.True(t, strings.HasPrefix(first, mqttClientIDPrefix), first)
+	assert.LessOrEqual(t, len(first), 23)
+	assert.NotEqual(t, first, second)
+
+	transport.ClientID = "meter"
+	assert.Equal(t, "meter", transport.clientOptions(writer).ClientID)
+}
+
+func TestMQTTName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "tcp://broker:1883/dsmr/raw", (&MQTTTransport{Address: "broker:1883", Topic: "dsmr/raw"}).Name())
+	assert.Equal(t, "ssl://broker:8883/dsmr/raw", (&MQTTTransport{Address: "ssl://broker:8883/", Topic: "dsmr/raw"}).Name())
+}
+
+func TestTerminateMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg            string
+		linePerMessage bool
+		result         string
+	}{
+		{"", false, ""},
+		{"1-3:0.2.8(50)", false, "1-3:0.2.8(50)"},
+		{"1-3:0.2.8(50)", true, "1-3:0.2.8(50)\r\n"},
+		{"1-3:0.2.8(50)\r\n", true, "1-3:0.2.8(50)\r\n"},
+		{"1-3:0.2.8(50)\r\n!", false, "1-3:0.2.8(50)\r\n!\r\n"},
+		{"1-3:0.2.8(50)\r\n!D75", false, "1-3:0.2.8(50)\r\n!D75"},
+		{"1-3:0.2.8(50)\r\n!D75E", false, "1-3:0.2.8(50)\r\n!D75E\r\n"},
+		{"!D75E\r\n", false, "!D75E\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.result, string(terminateMessage([]byte(test.msg), test.linePerMessage)))
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package gop1
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OBISWildcard is the value of a group of an OBISCode matching any value,
+	// written as * in an OBIS reduced ID
+	OBISWildcard = -1
+
+	obisGroups   = 5
+	obisGroupMax = 255
+)
+
+// ErrInvalidOBISCode is returned when parsing a code that is not formatted as
+// an OBIS reduced ID, like 1-0:1.8.1 or 0-*:24.2.1
+var ErrInvalidOBISCode = errors.New("invalid OBIS code")
+
+// OBISCode is an OBIS reduced ID, formatted as A-B:C.D.E. A is the medium, B
+// the channel, C the physical quantity, D the kind of processing and E the
+// tariff or further classification. Every group can be OBISWildcard, for
+// matching codes.
+type OBISCode struct {
+	A, B, C, D, E int
+}
+
+// ParseOBIS parses an OBIS reduced ID like 1-0:1.8.1, in which groups can be
+// a * wildcard like 0-*:24.2.1
+func ParseOBIS(code string) (OBISCode, error) {
+	a, rest, ok1 := strings.Cut(code, "-")
+	b, rest, ok2 := strings.Cut(rest, ":")
+	groups := append([]string{a, b}, strings.Split(rest, ".")...)
+
+	if !ok1 || !ok2 || len(groups) != obisGroups {
+		return OBISCode{}, fmt.Errorf("%w: %q", ErrInvalidOBISCode, code)
+	}
+
+	values := make([]int, obisGroups)
+
+	for i, group := range groups {
+		if group == "*" {
+			values[i] = OBISWildcard
+
+			continue
+		}
+
+		// only plain digits, so signs and spaces are rejected
+		if group == "" || strings.Trim(group, "0123456789") != "" {
+			return OBISCode{}, fmt.Errorf("%w: %q", ErrInvalidOBISCode, code)
+		}
+
+		value, err := strconv.Atoi(group)
+		if err != nil || value > obisGroupMax {
+			return OBISCode{}, fmt.Errorf("%w: %q", ErrInvalidOBISCode, code)
+		}
+
+		values[i] = value
+	}
+
+	return OBISCode{A: values[0], B: values[1], C: values[2], D: values[3], E: values[4]}, nil
+}
+
+// MustParseOBIS is like ParseOBIS but panics when the code can't be parsed,
+// for initializing variables holding OBIS codes
+func MustParseOBIS(code string) OBISCode {
+	parsed, err := ParseOBIS(code)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+// String returns the code formatted as an OBIS reduced ID
+func (c OBISCode) String() string {
+	groups := c.groups()
+	formatted := make([]string, obisGroups)
+
+	for i, group := range groups {
+		if group == OBISWildcard {
+			formatted[i] = "*"
+		} else {
+			formatted[i] = strconv.Itoa(group)
+		}
+	}
+
+	return fmt.Sprintf("%s-%s:%s.%s.%s", formatted[0], formatted[1], formatted[2], formatted[3], formatted[4])
+}
+
+// Compare returns -1, 0 or +1 when the code sorts before, equal to or after
+// given code, comparing group by group
+func (c OBISCode) Compare(other OBISCode) int {
+	groups, others := c.groups(), other.groups()
+
+	for i := range groups {
+		if n := cmp.Compare(groups[i], others[i]); n != 0 {
+			return n
+		}
+	}
+
+	return 0
+}
+
+// Match reports whether given code matches this code, in which wildcard
+// groups match any value
+func (c OBISCode) Match(code OBISCode) bool {
+	groups, others := c.groups(), code.groups()
+
+	for i := range groups {
+		if groups[i] != OBISWildcard && groups[i] != others[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsWildcard reports whether any of the groups of the code is a wildcard
+func (c OBISCode) IsWildcard() bool {
+	for _, group := range c.groups() {
+		if group == OBISWildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AnyChannel returns the code with a wildcard channel, which makes it easy to
+// switch on codes regardless of their M-Bus channel
+func (c OBISCode) AnyChannel() OBISCode {
+	c.B = OBISWildcard
+
+	return c
+}
+
+func (c OBISCode) groups() [obisGroups]int {
+	return [obisGroups]int{c.A, c.B, c.C, c.D, c.E}
+}
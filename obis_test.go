@@ -0,0 +1,102 @@
+package gop1
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOBIS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		code   string
+		result OBISCode
+		err    bool
+	}{
+		{code: "1-0:1.8.1", result: OBISCode{A: 1, B: 0, C: 1, D: 8, E: 1}},
+		{code: "0-1:24.2.1", result: OBISCode{A: 0, B: 1, C: 24, D: 2, E: 1}},
+		{code: "0-*:24.2.1", result: OBISCode{A: 0, B: OBISWildcard, C: 24, D: 2, E: 1}},
+		{code: "*-*:*.*.*", result: OBISCode{OBISWildcard, OBISWildcard, OBISWildcard, OBISWildcard, OBISWildcard}},
+		{code: "1-0:99.97.0", result: OBISCode{A: 1, B: 0, C: 99, D: 97, E: 0}},
+		{code: "", err: true},
+		{code: "1.8.1", err: true},
+		{code: "1-0:1.8", err: true},
+		{code: "1-0:1.8.1.0", err: true},
+		{code: "1-0:1.8.-1", err: true},
+		{code: "1-0:1.8.+1", err: true},
+		{code: "1-0:1.8.256", err: true},
+		{code: "1-0:1.8.", err: true},
+		{code: "1-0:1.8.1*", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.code, func(t *testing.T) {
+			t.Parallel()
+
+			code, err := ParseOBIS(test.code)
+			if test.err {
+				require.ErrorIs(t, err, ErrInvalidOBISCode)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.result, code)
+			assert.Equal(t, test.code, code.String())
+		})
+	}
+}
+
+func TestMustParseOBIS(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, OBISCode{A: 1, B: 0, C: 1, D: 8, E: 1}, MustParseOBIS("1-0:1.8.1"))
+	assert.Panics(t, func() { MustParseOBIS("1.8.1") })
+}
+
+func TestOBISCodeCompare(t *testing.T) {
+	t.Parallel()
+
+	codes := []OBISCode{
+		MustParseOBIS("1-0:2.8.1"),
+		MustParseOBIS("0-1:24.2.1"),
+		MustParseOBIS("1-0:1.8.2"),
+		MustParseOBIS("1-0:1.8.1"),
+	}
+
+	slices.SortFunc(codes, OBISCode.Compare)
+
+	assert.Equal(t, []OBISCode{
+		MustParseOBIS("0-1:24.2.1"),
+		MustParseOBIS("1-0:1.8.1"),
+		MustParseOBIS("1-0:1.8.2"),
+		MustParseOBIS("1-0:2.8.1"),
+	}, codes)
+	assert.Equal(t, 0, codes[0].Compare(MustParseOBIS("0-1:24.2.1")))
+}
+
+func TestOBISCodeMatch(t *testing.T) {
+	t.Parallel()
+
+	gas := MustParseOBIS("0-*:24.2.1")
+	assert.True(t, gas.IsWildcard())
+	assert.True(t, gas.Match(MustParseOBIS("0-1:24.2.1")))
+	assert.True(t, gas.Match(MustParseOBIS("0-4:24.2.1")))
+	assert.False(t, gas.Match(MustParseOBIS("0-1:24.2.3")))
+	assert.False(t, gas.Match(MustParseOBIS("1-1:24.2.1")))
+
+	code := MustParseOBIS("1-0:1.8.1")
+	assert.False(t, code.IsWildcard())
+	assert.True(t, code.Match(code))
+	assert.False(t, code.Match(MustParseOBIS("1-0:1.8.2")))
+
+	// codes of any channel can be switched on
+	switch MustParseOBIS("0-2:24.2.1").AnyChannel() {
+	case gas:
+	default:
+		t.Error("expected to match any channel")
+	}
+}
@@ -2,96 +2,497 @@
 package gop1
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"io"
-	"strings"
+	"sync"
 	"time"
-
-	"github.com/tarm/serial"
 )
 
 const (
 	defaultBaudrate = 115200
 	defaultTimeout  = 500
 	crcDelimiter    = '\x21' // hex char code for !
+	headerDelimiter = '\x2f' // hex char code for /
 )
 
+// ErrClosed is reported by P1.Err when reading stopped because P1 was closed
+var ErrClosed = errors.New("P1 closed")
+
 // P1 allows you to easily read from a P1-compatible serial device. The output is
 // parsed into structured data
 type P1 struct {
+	config       P1Config
 	serialDevice io.Reader
+	// transport is used to reopen the serial device, device is its name
+	transport Transport
+	device    string
+	// serial devices report an expired read timeout as io.EOF, which should not
+	// be mistaken for the end of the input
+	readTimeouts bool
 	Incoming     chan *Telegram
+	// powerFailures detects power failures for OnPowerFailure
+	powerFailures PowerFailureDetector
+
+	cancel       context.CancelCauseFunc
+	done         chan struct{}
+	incomingOnce sync.Once
+	mu           sync.Mutex
+	deviceClosed bool
+	closeErr     error
+	err          error
 }
 
 // P1Config is the configuration to create a new P1 object with
 type P1Config struct {
 	USBDevice string
 	Baudrate  int
+	DataBits  int
+	Parity    Parity
+	StopBits  int
 	Timeout   int // in milliseconds
+	// MaxTelegramSize is the maximum size of a telegram in bytes, larger
+	// telegrams are dropped
+	MaxTelegramSize int
+	// DropInvalidCRC makes P1 drop telegrams of which the CRC doesn't match,
+	// rather than sending them to Incoming with CRCStatus set to CRCInvalid
+	DropInvalidCRC bool
+	// OnError is called for every problem encountered while reading, such as
+	// a *ReadError, ErrTelegramTooLarge, a *CRCError or a *LineError for a
+	// line that could not be parsed. It is called from the goroutine reading
+	// the serial device, so it should return quickly.
+	OnError func(error)
+	// Reconnect makes P1 close and reopen the serial device when reading from
+	// it fails, rather than stopping. Incoming stays open while reconnecting.
+	Reconnect bool
+	// ReconnectMinDelay is the delay before the first attempt to reopen the
+	// serial device, which doubles with every failed attempt up to
+	// ReconnectMaxDelay
+	ReconnectMinDelay int // in milliseconds
+	ReconnectMaxDelay int // in milliseconds
+	// ResolveByID makes P1 reopen the serial device through its stable symlink
+	// in /dev/serial/by-id, since USB serial devices might come back under a
+	// different name
+	ResolveByID bool
+	// OnConnectionEvent is called whenever the serial device is disconnected,
+	// being reconnected or connected again
+	OnConnectionEvent func(ConnectionEvent)
+	// AutoDetect makes New probe the serial line settings of the known DSMR
+	// versions until a telegram comes through, instead of using the configured
+	// serial line settings. P1.Serial reports the settings that were chosen.
+	AutoDetect    bool
+	DetectTimeout int // in milliseconds, per probed setting
+	// DialTimeout and KeepAlive configure the connection of NewTCP and
+	// NewRFC2217
+	DialTimeout int // in milliseconds
+	KeepAlive   int // in milliseconds
+	// Recorder, when set, records every raw telegram that was read. It is not
+	// closed by P1.
+	Recorder *Recorder
+	// Registry maps the OBIS codes of telegram lines to their types, defaults
+	// to the global registry
+	Registry *OBISRegistry
+	// OnPowerFailure is called for every long power failure added to the
+	// event log of the meter while reading. Telegrams failing the CRC check
+	// are not taken into account.
+	OnPowerFailure func(PowerFailure)
 }
 
 // New returns a P1 object with given configuration or error when something went
 // wrong initializing the serial object
 func New(config P1Config) (*P1, error) {
-	if config.Baudrate <= 0 {
-		config.Baudrate = defaultBaudrate
-	}
+	config = serialDefaults(config)
 
 	if config.Timeout <= 0 {
 		config.Timeout = defaultTimeout
 	}
 
-	serialConfig := &serial.Config{
-		Name:        config.USBDevice,
-		Baud:        config.Baudrate,
-		ReadTimeout: time.Millisecond * time.Duration(config.Timeout),
+	if config.ResolveByID {
+		config.USBDevice = resolveByID(serialByIDDir, config.USBDevice)
 	}
 
-	serialDevice, err := serial.OpenPort(serialConfig)
+	if config.AutoDetect {
+		settings, err := detectSerial(config, func(settings SerialSettings) (io.ReadCloser, error) {
+			transport := &serialTransport{config: config.WithSerial(settings)}
+
+			return transport.Open(context.Background())
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		config = config.WithSerial(settings)
+	}
+
+	p1, err := NewFromTransport(&serialTransport{config: config}, config)
+	if err != nil {
+		return nil, err
+	}
+
+	p1.readTimeouts = true
+
+	return p1, nil
+}
+
+// NewFromTransport returns a P1 object reading from a connection opened with
+// given transport, which is reopened when P1Config.Reconnect is set. The serial
+// line settings in the configuration are left to the transport.
+func NewFromTransport(transport Transport, config P1Config) (*P1, error) {
+	reader, err := transport.Open(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
+	p1 := newP1(reader, config)
+	p1.transport = transport
+	p1.device = transport.Name()
+
+	return p1, nil
+}
+
+// NewFromReader returns a P1 object reading from given reader, such as a file
+// or a pipe. Reading stops when the reader returns io.EOF, the reader is closed
+// when P1 is closed.
+func NewFromReader(reader io.ReadCloser, config P1Config) *P1 {
+	return newP1(reader, config)
+}
+
+func newP1(serialDevice io.Reader, config P1Config) *P1 {
+	if config.ReconnectMinDelay <= 0 {
+		config.ReconnectMinDelay = defaultReconnectMinDelay
+	}
+
+	if config.Registry == nil {
+		config.Registry = defaultRegistry
+	}
+
+	if config.ReconnectMaxDelay < config.ReconnectMinDelay {
+		config.ReconnectMaxDelay = max(defaultReconnectMaxDelay, config.ReconnectMinDelay)
+	}
+
 	return &P1{
+		config:       config,
 		serialDevice: serialDevice,
 		Incoming:     make(chan *Telegram),
-	}, nil
+		done:         make(chan struct{}),
+	}
+}
+
+// Serial returns the serial line settings the serial device was opened with
+func (p *P1) Serial() SerialSettings {
+	return p.config.Serial()
+}
+
+// Start makes P1 start reading data from the serial device until given context
+// is cancelled, Close is called or the input ends. Incoming is closed when
+// reading stops, after which Err reports why. Start should only be called once.
+func (p *P1) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancelCause(ctx)
+
+	go p.run(ctx)
 }
 
-// Start makes P1 start reading data from the serial device
-func (p *P1) Start() {
-	go p.readData()
+// Close stops reading, closes the serial device and waits for Incoming to be
+// closed
+func (p *P1) Close() error {
+	if p.cancel != nil {
+		p.cancel(ErrClosed)
+		<-p.done
+	} else {
+		p.incomingOnce.Do(func() { close(p.Incoming) })
+	}
+
+	_ = p.closeSerialDevice()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.closeErr
 }
 
-func (p *P1) readData() {
-	// open reader to serial device
-	reader := bufio.NewReader(p.serialDevice)
+// Err returns the reason P1 stopped reading once Incoming is closed: ErrClosed
+// after Close, the cause of the context passed to Start when it was cancelled
+// or nil when the input simply ended
+func (p *P1) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+func (p *P1) run(ctx context.Context) {
+	defer close(p.done)
+
+	// closing the serial device is the only way to interrupt a pending read
+	stop := context.AfterFunc(ctx, func() {
+		_ = p.closeSerialDevice()
+	})
+	defer stop()
+
+	err := p.supervise(ctx)
 
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+
+	p.incomingOnce.Do(func() { close(p.Incoming) })
+}
+
+// supervise reads from the serial device and, when configured to do so,
+// reopens it whenever reading fails
+func (p *P1) supervise(ctx context.Context) error {
 	for {
-		// telegram data is suffixed with a CRC code
-		// this CRC code starts with ! so let's read until we receive that char
-		message, err := reader.ReadString(crcDelimiter)
+		err := p.readData(ctx, p.currentDevice())
+
+		var readErr *ReadError
+		if !p.config.Reconnect || p.transport == nil || !errors.As(err, &readErr) {
+			return err
+		}
+
+		_ = p.closeSerialDevice()
+		p.reportConnectionEvent(ConnectionEvent{State: StateDisconnected, Device: p.device, Err: err})
+
+		if err := p.reconnect(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// reconnect reopens the serial device with exponential backoff until it
+// succeeds or given context is cancelled
+func (p *P1) reconnect(ctx context.Context) error {
+	delay := time.Millisecond * time.Duration(p.config.ReconnectMinDelay)
+	maxDelay := time.Millisecond * time.Duration(p.config.ReconnectMaxDelay)
+
+	for attempt := 1; ; attempt++ {
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+
+		p.reportConnectionEvent(ConnectionEvent{State: StateReconnecting, Device: p.device, Attempt: attempt})
+
+		serialDevice, err := p.transport.Open(ctx)
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
+			p.reportConnectionEvent(ConnectionEvent{
+				State:   StateDisconnected,
+				Device:  p.device,
+				Attempt: attempt,
+				Err:     err,
+			})
+
+			delay = min(delay*2, maxDelay)
 
 			continue
 		}
 
-		lines := strings.Split(message, "\n")
-		p.Incoming <- parseTelegram(lines)
+		p.mu.Lock()
+		p.serialDevice = serialDevice
+		p.deviceClosed = false
+		p.closeErr = nil
+		p.mu.Unlock()
+
+		// the context might have been cancelled while opening the device, in
+		// which case nothing will close it anymore
+		if ctx.Err() != nil {
+			_ = p.closeSerialDevice()
+
+			return context.Cause(ctx)
+		}
+
+		p.reportConnectionEvent(ConnectionEvent{State: StateConnected, Device: p.device, Attempt: attempt})
+
+		return nil
+	}
+}
+
+func (p *P1) currentDevice() io.Reader {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.serialDevice
+}
+
+func (p *P1) closeSerialDevice() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.deviceClosed {
+		return p.closeErr
+	}
+
+	p.deviceClosed = true
+
+	if closer, ok := p.serialDevice.(io.Closer); ok {
+		p.closeErr = closer.Close()
+	}
+
+	return p.closeErr
+}
+
+func (p *P1) readData(ctx context.Context, serialDevice io.Reader) error {
+	framer := NewFramer(serialDevice, p.config.MaxTelegramSize)
+	acker, _ := serialDevice.(acknowledger)
+
+	for {
+		frame, err := framer.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+
+			switch {
+			case errors.Is(err, ErrTelegramTooLarge):
+				p.reportError(err)
+				p.acknowledge(acker)
+
+				continue
+			case errors.Is(err, io.EOF) && p.readTimeouts:
+				// an unplugged serial device keeps reporting io.EOF as well
+				if !deviceGone(p.device) {
+					continue
+				}
+
+				err = &ReadError{Err: ErrDeviceGone}
+				p.reportError(err)
+
+				return err
+			case errors.Is(err, io.EOF):
+				// when reconnecting, the end of the input means the connection
+				// was lost, like when a TCP connection is closed
+				if !p.config.Reconnect || p.transport == nil {
+					return nil
+				}
+
+				err = &ReadError{Err: err}
+				p.reportError(err)
+
+				return err
+			default:
+				err = &ReadError{Err: err}
+				p.reportError(err)
+
+				return err
+			}
+		}
+
+		received := time.Now()
+
+		if p.config.Recorder != nil {
+			if err := p.config.Recorder.Record(frame, received); err != nil {
+				p.reportError(err)
+			}
+		}
+
+		// lines that could not be parsed are left out of the telegram
+		tgram, err := p.config.Registry.parseFrame(frame)
+		if err != nil {
+			p.reportLineErrors(err)
+		}
+
+		tgram.Received = received
+
+		if tgram.CRCStatus == CRCInvalid {
+			p.reportError(&CRCError{
+				Device:     tgram.Device,
+				Checksum:   frame.Checksum,
+				Calculated: crc16(frame.Data),
+			})
+
+			if p.config.DropInvalidCRC {
+				p.acknowledge(acker)
+
+				continue
+			}
+		}
+
+		// a corrupted event log could report power failures that never happened
+		if tgram.CRCStatus != CRCInvalid {
+			p.detectPowerFailures(tgram)
+		}
+
+		select {
+		case p.Incoming <- tgram:
+			p.acknowledge(acker)
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+// acknowledge tells given reader, if any, that P1 is done with the telegram
+// it read last
+func (p *P1) acknowledge(acker acknowledger) {
+	if acker == nil {
+		return
+	}
+
+	if err := acker.acknowledge(); err != nil {
+		p.reportError(err)
+	}
+}
+
+func (p *P1) reportError(err error) {
+	if p.config.OnError != nil {
+		p.config.OnError(err)
+	}
+}
+
+// detectPowerFailures reports power failures added to the event log of given
+// telegram
+func (p *P1) detectPowerFailures(tgram *Telegram) {
+	if p.config.OnPowerFailure == nil {
+		return
+	}
+
+	failures, err := p.powerFailures.Detect(tgram)
+	if err != nil {
+		// not every meter keeps a power failure log
+		if !errors.Is(err, ErrObjectNotFound) {
+			p.reportError(err)
+		}
+
+		return
 	}
 
-	close(p.Incoming)
+	for _, failure := range failures {
+		p.config.OnPowerFailure(failure)
+	}
+}
+
+func (p *P1) reportConnectionEvent(event ConnectionEvent) {
+	if p.config.OnConnectionEvent != nil {
+		p.config.OnConnectionEvent(event)
+	}
+}
+
+// reportLineErrors reports the errors parsing a telegram one by one
+func (p *P1) reportLineErrors(err error) {
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		for _, e := range joined.Unwrap() {
+			p.reportError(e)
+		}
+
+		return
+	}
+
+	p.reportError(err)
 }
 
 // Telegram represents the structured data for one complete dump (or telegram)
 // of P1 data
 type Telegram struct {
-	Device  string
-	Objects []*TelegramObject
+	Device    string
+	Objects   []*TelegramObject
+	CRCStatus CRCStatus
+	// Checksum is the CRC as sent by the meter, empty when not present
+	Checksum string
+	// Raw is the telegram exactly as it was read, from its / header up to and
+	// including its CRC
+	Raw []byte
+	// Received is the time the telegram was read by the host. It is not set
+	// for telegrams parsed with ParseTelegram.
+	Received time.Time
 }
 
 // TelegramObject is the structured representation of a sinle line in a P1 data
@@ -99,6 +500,10 @@ type Telegram struct {
 type TelegramObject struct {
 	Type   OBISType
 	Values []TelegramValue
+	// Code is the OBIS code of the line, like 1-0:1.8.1
+	Code OBISCode
+	// Line is the line of the telegram this object was parsed from
+	Line string
 }
 
 // TelegramValue is one value of a P1 data line, optionally with a specific unit
@@ -154,4 +559,13 @@ const (
 	OBISTypeLimiterThreshold              = "Electricity limiter threshold"
 	OBISTypeFuseThresholdL1               = "Fuse threshold on phase L1"
 	OBISTypeGasValveState                 = "Gas valve state"
+	OBISTypeSlaveElectricityDelivered     = "Actual electricity delivered (slave meter)"
+	OBISTypeHeatDelivered                 = "Actual heat delivered"
+	OBISTypeWarmWaterDelivered            = "Actual warm water delivered"
+	OBISTypeWaterDelivered                = "Actual water delivered"
+	OBISTypeMBusEquipmentIdentifier       = "Equipment Identifier (M-Bus)"
+
+	// OBISTypeUnknown is the type of lines with an OBIS code that is not
+	// supported. Their Code holds the OBIS code.
+	OBISTypeUnknown = "Unknown"
 )
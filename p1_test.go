@@ -2,8 +2,14 @@ package gop1
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,12 +22,8 @@ func TestReadData(t *testing.T) {
 	require.NoError(t, err)
 
 	// create p1 with fake io.reader
-	p1 := P1{
-		serialDevice: bytes.NewReader(testdata),
-		Incoming:     make(chan *Telegram),
-	}
-
-	go p1.readData()
+	p1 := NewFromReader(io.NopCloser(bytes.NewReader(testdata)), P1Config{})
+	p1.Start(t.Context())
 
 	telegrams := make([]*Telegram, 0)
 	for telegram := range p1.Incoming {
@@ -30,4 +32,281 @@ func TestReadData(t *testing.T) {
 
 	assert.Len(t, telegrams, 1)
 	assert.Len(t, telegrams[0].Objects, 35)
+	require.NoError(t, p1.Err())
+	require.NoError(t, p1.Close())
+}
+
+func TestReadDataCRC(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file           string
+		dropInvalidCRC bool
+		telegrams      int
+		status         CRCStatus
+	}{
+		{"testdata/crc/valid", false, 1, CRCValid},
+		{"testdata/crc/valid", true, 1, CRCValid},
+		{"testdata/crc/invalid", false, 1, CRCInvalid},
+		{"testdata/crc/invalid", true, 0, CRCInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s_%t", test.file, test.dropInvalidCRC), func(t *testing.T) {
+			t.Parallel()
+
+			testdata, err := os.ReadFile(test.file)
+			require.NoError(t, err)
+
+			// follow the telegram with one without CRC, like DSMR 2.2 meters send
+			testdata = append(testdata, []byte("/KMP5 KA6U001585575011\r\n\r\n0-0:96.1.1(204B413655303031353835353735303131)\r\n!\r\n")...)
+
+			p1 := newP1(bytes.NewReader(testdata), P1Config{DropInvalidCRC: test.dropInvalidCRC})
+			p1.Start(t.Context())
+
+			telegrams := make([]*Telegram, 0)
+			for telegram := range p1.Incoming {
+				telegrams = append(telegrams, telegram)
+			}
+
+			require.Len(t, telegrams, test.telegrams+1)
+
+			if test.telegrams > 0 {
+				assert.Equal(t, test.status, telegrams[0].CRCStatus)
+				assert.True(t, bytes.HasPrefix(testdata, telegrams[0].Raw))
+				assert.True(t, bytes.HasSuffix(telegrams[0].Raw, []byte("!E47C")))
+				assert.WithinDuration(t, time.Now(), telegrams[0].Received, time.Minute)
+				assert.Equal(t, `ISk5\2MT382-1000`, telegrams[0].Device)
+				assert.Len(t, telegrams[0].Objects, 35)
+			}
+
+			assert.Equal(t, CRCNotPresent, telegrams[len(telegrams)-1].CRCStatus)
+			assert.Equal(t, "KMP5 KA6U001585575011", telegrams[len(telegrams)-1].Device)
+		})
+	}
+}
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	testdata, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	reader, writer := io.Pipe()
+	p1 := newP1(reader, P1Config{})
+	p1.Start(t.Context())
+
+	go func() {
+		_, _ = writer.Write(testdata)
+	}()
+
+	telegram := <-p1.Incoming
+	assert.Equal(t, CRCValid, telegram.CRCStatus)
+
+	// reading is blocked on the pipe now
+	require.NoError(t, p1.Close())
+
+	_, ok := <-p1.Incoming
+	assert.False(t, ok)
+	require.ErrorIs(t, p1.Err(), ErrClosed)
+
+	// the reader was closed as well
+	_, err = writer.Write(testdata)
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+
+	// closing again is harmless
+	require.NoError(t, p1.Close())
+}
+
+func TestCloseWithoutStart(t *testing.T) {
+	t.Parallel()
+
+	p1 := newP1(bytes.NewReader(nil), P1Config{})
+	require.NoError(t, p1.Close())
+
+	_, ok := <-p1.Incoming
+	assert.False(t, ok)
+}
+
+func TestCancel(t *testing.T) {
+	t.Parallel()
+
+	testdata, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	p1 := newP1(bytes.NewReader(bytes.Repeat(testdata, 3)), P1Config{})
+	p1.Start(ctx)
+
+	// don't consume any telegrams, P1 should still stop
+	cancel()
+
+	<-p1.done
+
+	for range p1.Incoming {
+		t.Fatal("no telegrams expected after cancelling")
+	}
+
+	require.ErrorIs(t, p1.Err(), context.Canceled)
+	require.NoError(t, p1.Close())
+}
+
+// fakeTransport opens the next of its readers every time, or fails when there
+// is no reader for the attempt
+type fakeTransport struct {
+	readers []io.Reader
+	opened  int
+}
+
+func (t *fakeTransport) Open(context.Context) (io.ReadCloser, error) {
+	t.opened++
+	if len(t.readers) < t.opened || t.readers[t.opened-1] == nil {
+		return nil, os.ErrNotExist
+	}
+
+	return io.NopCloser(t.readers[t.opened-1]), nil
+}
+
+func (t *fakeTransport) Name() string {
+	return "/dev/ttyUSB0"
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestOnError(t *testing.T) {
+	t.Parallel()
+
+	invalid, err := os.ReadFile("testdata/crc/invalid")
+	require.NoError(t, err)
+
+	unplugged := errors.New("device unplugged")
+	input := io.MultiReader(
+		bytes.NewReader(invalid),
+		strings.NewReader("/ISk5\\2MT382-1000\r\n\r\n1-3:0.2.8(50)\r\n1-100:0.2.8(0)\r\n1-0:1.8.1(1*kWh\r\n!\r\n"),
+		strings.NewReader("/"+strings.Repeat("x", defaultMaxTelegramSize)+"\r\n!\r\n"),
+		errReader{unplugged},
+	)
+
+	var errs []error
+
+	p1 := newP1(input, P1Config{
+		OnError: func(err error) {
+			errs = append(errs, err)
+		},
+	})
+	p1.Start(t.Context())
+
+	telegrams := make([]*Telegram, 0)
+	for telegram := range p1.Incoming {
+		telegrams = append(telegrams, telegram)
+	}
+
+	require.Len(t, telegrams, 2)
+	assert.Len(t, telegrams[1].Objects, 2)
+
+	require.Len(t, errs, 5)
+
+	var crcErr *CRCError
+	require.ErrorAs(t, errs[0], &crcErr)
+	assert.Equal(t, "E47C", crcErr.Checksum)
+	assert.Equal(t, `ISk5\2MT382-1000`, crcErr.Device)
+
+	// the unknown OBIS code is reported, but its object is kept
+	var lineErr *LineError
+	require.ErrorAs(t, errs[1], &lineErr)
+	assert.Equal(t, 4, lineErr.Line)
+	require.ErrorIs(t, errs[1], ErrUnknownOBIS)
+	require.ErrorAs(t, errs[2], &lineErr)
+	assert.Equal(t, 5, lineErr.Line)
+	require.ErrorIs(t, errs[2], ErrMalformedLine)
+
+	require.ErrorIs(t, errs[3], ErrTelegramTooLarge)
+
+	var readErr *ReadError
+	require.ErrorAs(t, errs[4], &readErr)
+	require.ErrorIs(t, errs[4], unplugged)
+
+	// reading stopped because of the read error
+	require.ErrorIs(t, p1.Err(), unplugged)
+}
+
+func TestReconnect(t *testing.T) {
+	t.Parallel()
+
+	testdata, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	unplugged := errors.New("device unplugged")
+
+	var events []ConnectionEvent
+
+	p1, err := NewFromTransport(&fakeTransport{
+		readers: []io.Reader{
+			io.MultiReader(bytes.NewReader(testdata), errReader{unplugged}),
+			nil,
+			bytes.NewReader(testdata),
+		},
+	}, P1Config{
+		Reconnect:         true,
+		ReconnectMinDelay: 1,
+		ReconnectMaxDelay: 2,
+		OnConnectionEvent: func(event ConnectionEvent) {
+			events = append(events, event)
+		},
+	})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	telegrams := make([]*Telegram, 0)
+	for telegram := range p1.Incoming {
+		telegrams = append(telegrams, telegram)
+		if len(telegrams) == 2 {
+			require.NoError(t, p1.Close())
+		}
+	}
+
+	assert.Len(t, telegrams, 2)
+	require.ErrorIs(t, p1.Err(), ErrClosed)
+
+	require.GreaterOrEqual(t, len(events), 5)
+	assert.Equal(t, StateDisconnected, events[0].State)
+	require.ErrorIs(t, events[0].Err, unplugged)
+	assert.Equal(t, ConnectionEvent{State: StateReconnecting, Device: "/dev/ttyUSB0", Attempt: 1}, events[1])
+	assert.Equal(t, StateDisconnected, events[2].State)
+	require.ErrorIs(t, events[2].Err, os.ErrNotExist)
+	assert.Equal(t, ConnectionEvent{State: StateReconnecting, Device: "/dev/ttyUSB0", Attempt: 2}, events[3])
+	assert.Equal(t, ConnectionEvent{State: StateConnected, Device: "/dev/ttyUSB0", Attempt: 2}, events[4])
+}
+
+func TestNewFromTransport(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFromTransport(&fakeTransport{}, P1Config{})
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestReconnectCancel(t *testing.T) {
+	t.Parallel()
+
+	p1, err := NewFromTransport(&fakeTransport{
+		readers: []io.Reader{errReader{errors.New("device unplugged")}},
+	}, P1Config{
+		Reconnect:         true,
+		ReconnectMinDelay: 1,
+	})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	// P1 keeps trying to reconnect until closed
+	require.NoError(t, p1.Close())
+
+	_, ok := <-p1.Incoming
+	assert.False(t, ok)
+	require.ErrorIs(t, p1.Err(), ErrClosed)
 }
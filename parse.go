@@ -1,7 +1,10 @@
 package gop1
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"regexp"
 	"strings"
 )
@@ -11,7 +14,19 @@ const (
 )
 
 var (
-	errCOSEMNoMatch     = errors.New("COSEM was no match")
+	// ErrIncompleteTelegram is returned when data doesn't contain a telegram
+	// from its / header up to its ! delimiter
+	ErrIncompleteTelegram = errors.New("incomplete telegram")
+	// ErrMalformedLine is returned for telegram lines not formatted as an OBIS
+	// code followed by one or more values
+	ErrMalformedLine = errors.New("malformed telegram line")
+	// ErrUnknownOBIS is returned for telegram lines with an OBIS code that is
+	// not supported. The line is still kept as an object of type
+	// OBISTypeUnknown.
+	ErrUnknownOBIS = errors.New("unknown OBIS code")
+)
+
+var (
 	telegramHeaderRegex = regexp.MustCompile(`^/(.+)$`)
 	cosemOBISRegex      = regexp.MustCompile(`^(\d+-\d+:\d+\.\d+\.\d+)([0-9A-Za-z\(\)\*\-.:]+)$`)
 	cosemValsRegex      = regexp.MustCompile(`\(([^\)]+)\)`)
@@ -19,6 +34,8 @@ var (
 )
 
 var (
+	// allOBISTypes are the OBIS codes supported by this library, registered in
+	// every OBISRegistry
 	allOBISTypes = map[string]OBISType{
 		"1-3:0.2.8":   OBISTypeVersionInformation,
 		"0-0:1.0.0":   OBISTypeDateTimestamp,
@@ -62,23 +79,79 @@ var (
 
 	// In the specification, there are several OBIS types specified for slave
 	// devices as gas meters and such. These have variable OBIS IDs (first 2 digits)
-	// and this requires special treatment in the current way of parsing
+	// and are registered with a wildcard for their channel
 	addOBISTypes = map[string]OBISType{
-		`0-(\d+):96.1.0`: OBISTypeGasEquipmentIdentifier,
-		`0-(\d+):24.1.0`: OBISTypeDeviceType,
-		`0-(\d+):24.2.1`: OBISTypeGasDelivered,
+		"0-*:96.1.0": OBISTypeGasEquipmentIdentifier,
+		"0-*:24.1.0": OBISTypeDeviceType,
+		"0-*:24.2.1": OBISTypeGasDelivered,
 
-		`0-(\d+):96.1.1`: OBISTypeGasEquipmentIdentifier,
-		`0-(\d+):24.4.0`: OBISTypeGasValveState,
-		`0-(\d+):24.2.3`: OBISTypeGasDelivered,
+		"0-*:96.1.1": OBISTypeGasEquipmentIdentifier,
+		"0-*:24.4.0": OBISTypeGasValveState,
+		"0-*:24.2.3": OBISTypeGasDelivered,
 	}
 )
 
-// parsedTelegram parses lines from P1 data, or telegrams
-func parseTelegram(lines []string) *Telegram {
+// LineError describes a line of a telegram that could not be parsed
+type LineError struct {
+	Line int // line number within the telegram, starting at 1 for the header
+	Text string
+	Err  error
+}
+
+// Error implements the error interface
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v: %q", e.Line, e.Err, e.Text)
+}
+
+// Unwrap returns the underlying error
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ParseTelegram parses a single telegram, from its / header up to its !
+// delimiter optionally followed by the CRC. Data before the header or after
+// the CRC is ignored. When one or more lines of the telegram could not be
+// parsed, the returned telegram holds all other lines and the returned error
+// wraps a *LineError for every line that failed. Lines with an unsupported
+// OBIS code are kept as objects of type OBISTypeUnknown, but are reported as
+// a *LineError wrapping ErrUnknownOBIS as well.
+func ParseTelegram(data []byte) (*Telegram, error) {
+	return defaultRegistry.ParseTelegram(data)
+}
+
+// nextFrame returns the first telegram in given data
+func nextFrame(data []byte) (*Frame, error) {
+	frame, err := NewFramer(bytes.NewReader(data), len(data)+1).Next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrIncompleteTelegram
+		}
+
+		return nil, err
+	}
+
+	return frame, nil
+}
+
+// parseFrame parses a raw telegram read by Framer
+func (r *OBISRegistry) parseFrame(frame *Frame) (*Telegram, error) {
+	tgram, err := r.parseTelegram(strings.Split(string(frame.Data), "\n"))
+	tgram.CRCStatus = frame.CRCStatus()
+	tgram.Checksum = frame.Checksum
+	tgram.Raw = append(bytes.Clone(frame.Data), frame.Checksum...)
+
+	return tgram, err
+}
+
+// parseTelegram parses lines from P1 data, or telegrams
+func (r *OBISRegistry) parseTelegram(lines []string) (*Telegram, error) {
 	tgram := &Telegram{}
 
-	for _, l := range lines {
+	var errs []error
+
+	for i, l := range lines {
+		l = strings.TrimSpace(l)
+
 		// try to detect identification header
 		match := telegramHeaderRegex.FindStringSubmatch(l)
 		if len(match) > 0 {
@@ -87,60 +160,98 @@ func parseTelegram(lines []string) *Telegram {
 			continue
 		}
 
-		obj, err := parseTelegramLine(strings.TrimSpace(l))
-		if err != nil {
+		// skip the empty line following the header and the end of the telegram
+		if l == "" || l == string(crcDelimiter) {
 			continue
 		}
 
-		tgram.Objects = append(tgram.Objects, obj)
+		obj, err := r.parseTelegramLine(l)
+		if err != nil {
+			errs = append(errs, &LineError{Line: i + 1, Text: l, Err: err})
+		}
+
+		if obj != nil {
+			tgram.Objects = append(tgram.Objects, obj)
+		}
 	}
 
-	return tgram
+	classifyMBus(tgram)
+
+	return tgram, errors.Join(errs...)
 }
 
-func parseTelegramLine(line string) (*TelegramObject, error) {
+func (r *OBISRegistry) parseTelegramLine(line string) (*TelegramObject, error) {
 	matches := cosemOBISRegex.FindStringSubmatch(line)
 	if len(matches) != cosemMatchLength {
-		return nil, errCOSEMNoMatch
+		return nil, ErrMalformedLine
+	}
+
+	vmatches := cosemValsRegex.FindAllStringSubmatch(matches[2], -1)
+	if len(vmatches) == 0 {
+		return nil, ErrMalformedLine
+	}
+
+	values := make([]string, 0, len(vmatches))
+	for _, v := range vmatches {
+		values = append(values, v[1])
+	}
+
+	code, err := ParseOBIS(matches[1])
+	if err != nil {
+		return nil, ErrMalformedLine
+	}
+
+	// keep lines with unsupported OBIS codes, so they can still be inspected
+	obj := &TelegramObject{Type: OBISTypeUnknown, Code: code, Line: line}
+
+	entry, ok := r.lookup(code)
+	if !ok {
+		obj.Values = decodeValues(values)
+
+		return obj, ErrUnknownOBIS
 	}
 
-	var obj *TelegramObject
-	// is this a known COSEM object
-	if t, ok := allOBISTypes[matches[1]]; ok {
-		obj = &TelegramObject{Type: t}
+	obj.Type = entry.obisType
+
+	if entry.decoder == nil {
+		obj.Values = decodeValues(values)
 	} else {
-		// try to match it to one of the additional types
-		for ptr, obisType := range addOBISTypes {
-			if regexp.MustCompile(ptr).MatchString(matches[1]) {
-				obj = &TelegramObject{Type: obisType}
+		decoded, err := entry.decoder(values)
+		if err != nil {
+			return nil, err
+		}
 
-				break
+		obj.Values = decoded
+	}
+
+	if entry.unit != "" {
+		for _, v := range obj.Values {
+			if v.Unit != "" && !strings.EqualFold(v.Unit, entry.unit) {
+				return nil, fmt.Errorf("%w: %s instead of %s", ErrUnexpectedUnit, v.Unit, entry.unit)
 			}
 		}
 	}
 
-	if obj == nil {
-		return nil, errCOSEMNoMatch
-	}
+	return obj, nil
+}
 
-	vmatches := cosemValsRegex.FindAllStringSubmatch(matches[2], -1)
-	if len(vmatches) == 0 {
-		return nil, errCOSEMNoMatch
-	}
+// decodeValues splits values into a value and, when specified, its unit
+func decodeValues(values []string) []TelegramValue {
+	decoded := make([]TelegramValue, 0, len(values))
 
-	for _, v := range vmatches {
+	for _, v := range values {
 		ov := TelegramValue{}
 		// check if the unit of the value is specified as well
-		match := cosemUnitRegex.FindStringSubmatch(v[1])
+		match := cosemUnitRegex.FindStringSubmatch(v)
 		if len(match) > 1 {
 			ov.Value = match[1]
 			ov.Unit = match[2]
 		} else {
-			ov.Value = v[1]
+			ov.Value = v
 		}
 
-		obj.Values = append(obj.Values, ov)
+		decoded = append(decoded, ov)
 	}
 
-	return obj, nil
+	return decoded
 }
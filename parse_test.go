@@ -14,19 +14,36 @@ func TestParseTelegram(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		file    string
-		device  string
-		objects int
+		file     string
+		device   string
+		objects  int
+		checksum string
+		id       string
+		line     string
 	}{
 		{
-			file:    "testdata/parser/output0",
-			device:  `ISk5\2MT382-1000`,
-			objects: 35,
+			file:     "testdata/parser/output0",
+			device:   `ISk5\2MT382-1000`,
+			objects:  35,
+			checksum: "EF2F",
+			id:       "1-3:0.2.8",
+			line:     "1-3:0.2.8(50)",
 		},
 		{
-			file:    "testdata/parser/output1",
-			device:  `FLU5\493523491_A`,
-			objects: 24,
+			file:     "testdata/parser/output1",
+			device:   `FLU5\493523491_A`,
+			objects:  24,
+			checksum: "D75E",
+			id:       "0-0:96.1.4",
+			line:     "0-0:96.1.4(50)",
+		},
+		{
+			file:     "testdata/parser/output2",
+			device:   `FLU5\253770234_A`,
+			objects:  22,
+			checksum: "1D6E",
+			id:       "0-0:96.1.4",
+			line:     "0-0:96.1.4(50217)",
 		},
 	}
 
@@ -37,26 +54,96 @@ func TestParseTelegram(t *testing.T) {
 			fixture, err := os.ReadFile(test.file)
 			require.NoError(t, err)
 
-			lines := strings.Split(string(fixture), "\n")
-			tgram := parseTelegram(lines)
+			tgram, err := ParseTelegram(fixture)
+			require.NoError(t, err)
 
 			assert.Equal(t, test.device, tgram.Device)
 			assert.Len(t, tgram.Objects, test.objects)
+			assert.Equal(t, strings.TrimRight(string(fixture), "\r\n"), string(tgram.Raw))
+			assert.Equal(t, test.checksum, tgram.Checksum)
+			assert.True(t, tgram.Received.IsZero())
+
+			// objects keep the line they were parsed from
+			assert.Equal(t, test.id, tgram.Objects[0].Code.String())
+			assert.Equal(t, test.line, tgram.Objects[0].Line)
 		})
 	}
 }
 
+func TestParseTelegramErrors(t *testing.T) {
+	t.Parallel()
+
+	// telegram without delimiter
+	_, err := ParseTelegram([]byte("/ISk5\\2MT382-1000\r\n\r\n1-3:0.2.8(50)\r\n"))
+	require.ErrorIs(t, err, ErrIncompleteTelegram)
+
+	// telegram without header
+	_, err = ParseTelegram([]byte("1-3:0.2.8(50)\r\n!\r\n"))
+	require.ErrorIs(t, err, ErrIncompleteTelegram)
+
+	// telegram with broken lines
+	tgram, err := ParseTelegram([]byte("/ISk5\\2MT382-1000\r\n\r\n1-3:0.2.8(50)\r\n" +
+		"1-100:0.2.8(0)\r\n1-0:1.8.1(123456.789*kWh\r\n0-0:96.14.0(0002)\r\n!\r\n"))
+	require.Error(t, err)
+	require.NotNil(t, tgram)
+	assert.Len(t, tgram.Objects, 3)
+	assert.Equal(t, OBISType(OBISTypeUnknown), tgram.Objects[1].Type)
+	assert.Equal(t, OBISCode{A: 1, B: 100, C: 0, D: 2, E: 8}, tgram.Objects[1].Code)
+	assert.Equal(t, CRCNotPresent, tgram.CRCStatus)
+
+	var lineErr *LineError
+	require.ErrorAs(t, err, &lineErr)
+	assert.Equal(t, 4, lineErr.Line)
+	assert.Equal(t, "1-100:0.2.8(0)", lineErr.Text)
+	require.ErrorIs(t, err, ErrUnknownOBIS)
+	require.ErrorIs(t, err, ErrMalformedLine)
+	assert.EqualError(t, err, "line 4: unknown OBIS code: \"1-100:0.2.8(0)\"\n"+
+		"line 5: malformed telegram line: \"1-0:1.8.1(123456.789*kWh\"")
+}
+
 func TestParseTelegramLine(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		line        string
-		result      *TelegramObject
-		expectError bool
+		line   string
+		result *TelegramObject
+		err    error
 	}{
-		{"foo", nil, true},            // bogus
-		{"1-3:0.2.8(50", nil, true},   // missing )
-		{"1-100:0.2.8(0)", nil, true}, // unknown OBIS ID
+		{"foo", nil, ErrMalformedLine},          // bogus
+		{"1-3:0.2.8(50", nil, ErrMalformedLine}, // missing )
+		{
+			line: "1-100:0.2.8(0)",
+			result: &TelegramObject{
+				Type: OBISTypeUnknown,
+				Values: []TelegramValue{
+					{Value: "0"},
+				},
+			},
+			err: ErrUnknownOBIS,
+		},
+		{
+			// Belgian capacity tariff, maximum demand of the current month
+			line: "1-0:1.6.0(200509134558S)(01.111*kW)",
+			result: &TelegramObject{
+				Type: OBISTypeUnknown,
+				Values: []TelegramValue{
+					{Value: "200509134558S"},
+					{"01.111", "kW"},
+				},
+			},
+			err: ErrUnknownOBIS,
+		},
+		{
+			// Swedish reactive energy
+			line: "1-0:3.8.0(00000012.345*kvarh)",
+			result: &TelegramObject{
+				Type: OBISTypeUnknown,
+				Values: []TelegramValue{
+					{"00000012.345", "kvarh"},
+				},
+			},
+			err: ErrUnknownOBIS,
+		},
 		{
 			line: "1-3:0.2.8(50)",
 			result: &TelegramObject{
@@ -459,14 +546,36 @@ func TestParseTelegramLine(t *testing.T) {
 		t.Run(fmt.Sprintf("parse_%d", i), func(t *testing.T) {
 			t.Parallel()
 
-			obj, err := parseTelegramLine(test.line)
-			if test.expectError {
-				require.Error(t, err)
+			obj, err := defaultRegistry.parseTelegramLine(test.line)
+			if test.result == nil {
+				require.ErrorIs(t, err, test.err)
+				assert.Nil(t, obj)
+
+				return
+			}
+
+			// unknown OBIS codes are reported, but kept nonetheless
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
 			} else {
 				require.NoError(t, err)
 			}
 
-			assert.Equal(t, test.result, obj)
+			assert.Equal(t, test.result.Type, obj.Type)
+			assert.Equal(t, test.result.Values, obj.Values)
+			assert.Equal(t, test.line, obj.Line)
+			assert.Equal(t, test.line[:strings.IndexByte(test.line, '(')], obj.Code.String())
 		})
 	}
 }
+
+// timestampedTelegram returns the telegram in testdata/parser/output1 with
+// given timestamp instead of its own
+func timestampedTelegram(t *testing.T, timestamp string) string {
+	t.Helper()
+
+	fixture, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	return strings.Replace(string(fixture), "101209113020W", timestamp, 1)
+}
@@ -0,0 +1,113 @@
+package gop1
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PowerFailure is a long power failure, as logged by the meter
+type PowerFailure struct {
+	End      time.Time
+	Duration time.Duration
+}
+
+// Start returns the time the power failure started
+func (f PowerFailure) Start() time.Time {
+	return f.End.Add(-f.Duration)
+}
+
+// PowerFailures decodes the object as a power failure event log, which holds
+// the number of entries, the OBIS code of the event followed by the end time
+// and duration of every power failure. The power failures are ordered by their
+// end, oldest first.
+func (o *TelegramObject) PowerFailures() ([]PowerFailure, error) {
+	values := o.Values
+
+	// an empty log starts with (), which has no value
+	count := 0
+	if len(values) > 0 && len(values)%2 == 0 {
+		var err error
+		if count, err = values[0].Int(); err != nil {
+			return nil, err
+		}
+
+		values = values[1:]
+	}
+
+	// skip the OBIS code of the logged event
+	if len(values) > 0 {
+		values = values[1:]
+	}
+
+	if len(values) != 2*count {
+		return nil, fmt.Errorf("%w: power failure event log with %d entries has %d values", ErrInvalidValue, count, len(values))
+	}
+
+	failures := make([]PowerFailure, 0, count)
+
+	for i := 0; i < len(values); i += 2 {
+		end, err := values[i].Time()
+		if err != nil {
+			return nil, err
+		}
+
+		duration, err := values[i+1].Duration()
+		if err != nil {
+			return nil, err
+		}
+
+		failures = append(failures, PowerFailure{End: end, Duration: duration})
+	}
+
+	slices.SortStableFunc(failures, func(a, b PowerFailure) int { return a.End.Compare(b.End) })
+
+	return failures, nil
+}
+
+// PowerFailures returns the long power failures in the event log of the
+// telegram, oldest first
+func (t *Telegram) PowerFailures() ([]PowerFailure, error) {
+	obj := t.Object(OBISTypePowerFailureEventLog)
+	if obj == nil {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, OBISTypePowerFailureEventLog)
+	}
+
+	return obj.PowerFailures()
+}
+
+// PowerFailureDetector detects power failures that were added to the event
+// log of the meter. The log of the first telegram is taken as the starting
+// point, so only power failures that are logged later are detected. It is not
+// safe for concurrent use.
+type PowerFailureDetector struct {
+	latest  time.Time
+	started bool
+}
+
+// Detect returns the power failures that were logged since the previous
+// telegram passed to Detect, oldest first
+func (d *PowerFailureDetector) Detect(tgram *Telegram) ([]PowerFailure, error) {
+	failures, err := tgram.PowerFailures()
+	if err != nil {
+		return nil, err
+	}
+
+	var added []PowerFailure
+
+	for _, failure := range failures {
+		if !failure.End.After(d.latest) {
+			continue
+		}
+
+		d.latest = failure.End
+
+		if d.started {
+			added = append(added, failure)
+		}
+	}
+
+	d.started = true
+
+	return added, nil
+}
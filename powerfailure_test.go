@@ -0,0 +1,202 @@
+package gop1
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPowerFailures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		line     string
+		failures []PowerFailure
+		err      error
+	}{
+		{
+			line: "1-0:99.97.0(2)(0-0:96.7.19)(101208152415W)(0000000240*s)(101208151004W)(0000000301*s)",
+			failures: []PowerFailure{
+				{End: time.Date(2010, 12, 8, 14, 10, 4, 0, time.UTC), Duration: 301 * time.Second},
+				{End: time.Date(2010, 12, 8, 14, 24, 15, 0, time.UTC), Duration: 240 * time.Second},
+			},
+		},
+		{
+			line:     "1-0:99.97.0(0)(0-0:96.7.19)",
+			failures: []PowerFailure{},
+		},
+		{
+			line:     "1-0:99.97.0()(0-0:96.7.19)",
+			failures: []PowerFailure{},
+		},
+		{
+			line: "1-0:99.97.0(2)(0-0:96.7.19)(101208152415W)(0000000240*s)",
+			err:  ErrInvalidValue,
+		},
+		{
+			line: "1-0:99.97.0(1)(0-0:96.7.19)(101208152415W)(0000000240*kWh)",
+			err:  ErrUnexpectedUnit,
+		},
+		{
+			line: "1-0:99.97.0(1)(0-0:96.7.19)(foo)(0000000240*s)",
+			err:  ErrInvalidValue,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := defaultRegistry.parseTelegramLine(test.line)
+			require.NoError(t, err)
+
+			failures, err := obj.PowerFailures()
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, failures, len(test.failures))
+
+			for i, failure := range failures {
+				assert.True(t, test.failures[i].End.Equal(failure.End), failure.End)
+				assert.Equal(t, test.failures[i].Duration, failure.Duration)
+			}
+		})
+	}
+}
+
+func TestTelegramPowerFailures(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/parser/output0")
+	require.NoError(t, err)
+
+	tgram, err := ParseTelegram(fixture)
+	require.NoError(t, err)
+
+	failures, err := tgram.PowerFailures()
+	require.NoError(t, err)
+	require.Len(t, failures, 2)
+	assert.True(t, time.Date(2010, 12, 8, 14, 5, 3, 0, time.UTC).Equal(failures[0].Start()), failures[0].Start())
+
+	_, err = (&Telegram{}).PowerFailures()
+	require.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+// powerFailureTelegram returns a telegram with given power failure log entries
+func powerFailureTelegram(entries ...string) string {
+	return "/ISk5\\2MT382-1000\r\n\r\n1-0:99.97.0(" + strconv.Itoa(len(entries)/2) + ")(0-0:96.7.19)(" +
+		strings.Join(entries, ")(") + ")\r\n!\r\n"
+}
+
+func TestPowerFailureDetector(t *testing.T) {
+	t.Parallel()
+
+	telegrams := []string{
+		powerFailureTelegram("101208152415W", "0000000240*s"),
+		powerFailureTelegram("101208152415W", "0000000240*s"),
+		powerFailureTelegram("101209100000W", "0000000600*s", "101208152415W", "0000000240*s"),
+		powerFailureTelegram("101209100000W", "0000000600*s", "101208152415W", "0000000240*s"),
+		// the oldest entry rotated out while two were added
+		powerFailureTelegram("101210100000W", "0000000060*s", "101210090000W", "0000000030*s", "101209100000W", "0000000600*s"),
+	}
+
+	var detector PowerFailureDetector
+
+	var detected [][]PowerFailure
+
+	for _, telegram := range telegrams {
+		tgram, err := ParseTelegram([]byte(telegram))
+		require.NoError(t, err)
+
+		failures, err := detector.Detect(tgram)
+		require.NoError(t, err)
+
+		detected = append(detected, failures)
+	}
+
+	// the log of the first telegram is the starting point
+	assert.Empty(t, detected[0])
+	assert.Empty(t, detected[1])
+	require.Len(t, detected[2], 1)
+	assert.Equal(t, 600*time.Second, detected[2][0].Duration)
+	assert.Empty(t, detected[3])
+	require.Len(t, detected[4], 2)
+	assert.Equal(t, 30*time.Second, detected[4][0].Duration)
+	assert.Equal(t, 60*time.Second, detected[4][1].Duration)
+
+	_, err := detector.Detect(&Telegram{})
+	require.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+func TestOnPowerFailure(t *testing.T) {
+	t.Parallel()
+
+	input := powerFailureTelegram("101208152415W", "0000000240*s") +
+		"/ISk5\\2MT382-1000\r\n\r\n1-3:0.2.8(50)\r\n!\r\n" +
+		powerFailureTelegram("101209100000W", "0000000600*s", "101208152415W", "0000000240*s")
+
+	var failures []PowerFailure
+
+	p1 := newP1(bytes.NewReader([]byte(input)), P1Config{
+		OnPowerFailure: func(failure PowerFailure) {
+			failures = append(failures, failure)
+		},
+		OnError: func(err error) {
+			t.Errorf("unexpected error: %v", err)
+		},
+	})
+	p1.Start(t.Context())
+
+	telegrams := 0
+	for range p1.Incoming {
+		telegrams++
+	}
+
+	assert.Equal(t, 3, telegrams)
+	require.NoError(t, p1.Err())
+	require.Len(t, failures, 1)
+	assert.Equal(t, 600*time.Second, failures[0].Duration)
+}
+
+func TestOnPowerFailureInvalidCRC(t *testing.T) {
+	t.Parallel()
+
+	invalid, err := os.ReadFile("testdata/crc/invalid")
+	require.NoError(t, err)
+
+	// a corrupted timestamp in the event log, which fails the CRC check
+	corrupted := strings.Replace(string(invalid), "(101208152415W)", "(101209100000W)", 1)
+
+	input := powerFailureTelegram("101208152415W", "0000000240*s") + corrupted +
+		powerFailureTelegram("101209100000W", "0000000600*s", "101208152415W", "0000000240*s")
+
+	var failures []PowerFailure
+
+	p1 := newP1(bytes.NewReader([]byte(input)), P1Config{
+		OnPowerFailure: func(failure PowerFailure) {
+			failures = append(failures, failure)
+		},
+	})
+	p1.Start(t.Context())
+
+	var statuses []CRCStatus
+	for tgram := range p1.Incoming {
+		statuses = append(statuses, tgram.CRCStatus)
+	}
+
+	assert.Equal(t, []CRCStatus{CRCNotPresent, CRCInvalid, CRCNotPresent}, statuses)
+
+	// only the power failure in the intact telegram is reported
+	require.Len(t, failures, 1)
+	assert.Equal(t, 600*time.Second, failures[0].Duration)
+}
@@ -0,0 +1,265 @@
+package gop1
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	recorderTimeFormat  = time.RFC3339Nano
+	recorderRotateStamp = "20060102T150405"
+)
+
+// RecorderConfig is the configuration to create a new Recorder with
+type RecorderConfig struct {
+	// Path is the capture file to write to. Rotated files get the time of
+	// rotation inserted before their extension, like capture-20201209T113020.txt,
+	// followed by a sequence number when that file already exists, like
+	// capture-20201209T113020-1.txt
+	Path string
+	// MaxSize is the size in bytes of the capture file after which it is
+	// rotated, which is its compressed size when compressing
+	MaxSize int64
+	// RotateInterval is the time after which the capture file is rotated
+	RotateInterval int // in milliseconds
+	// Gzip compresses the capture file
+	Gzip bool
+}
+
+// Recorder writes raw telegrams to a capture file, each preceded by a comment
+// line with the time it was received and its CRC status. Since comments are
+// ignored when framing telegrams, captures can be replayed with
+// ReplayTransport.
+type Recorder struct {
+	config RecorderConfig
+	mu     sync.Mutex
+	closed bool
+	file   *os.File
+	writer io.Writer
+	gzip   *gzip.Writer
+	size   int64
+	opened time.Time
+	// retrySize is the size at which rotating is tried again after it failed,
+	// 0 when it didn't
+	retrySize  int64
+	nowFunc    func() time.Time
+	renameFunc func(oldpath, newpath string) error
+}
+
+// NewRecorder returns a Recorder writing to the configured capture file, which
+// is appended to when it already exists
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	recorder := &Recorder{
+		config:     config,
+		nowFunc:    time.Now,
+		renameFunc: os.Rename,
+	}
+
+	if err := recorder.open(); err != nil {
+		return nil, err
+	}
+
+	return recorder, nil
+}
+
+// Record writes given raw telegram, received at given time, to the capture
+// file and rotates the capture file when needed. When rotating fails, the
+// telegram is still written to the current capture file and rotating is tried
+// again after another MaxSize bytes or RotateInterval.
+func (r *Recorder) Record(frame *Frame, received time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+
+	var rotateErr error
+
+	if r.file != nil && r.shouldRotate() {
+		rotateErr = r.rotate()
+	}
+
+	// the capture file might not have been reopened after rotating
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return errors.Join(rotateErr, err)
+		}
+	}
+
+	return errors.Join(rotateErr, r.write(frame, received))
+}
+
+// write writes given raw telegram to the capture file
+func (r *Recorder) write(frame *Frame, received time.Time) error {
+	header := fmt.Sprintf("# received %s crc %s\r\n", received.Format(recorderTimeFormat), frame.CRCStatus())
+
+	if _, err := io.WriteString(r.writer, header); err != nil {
+		return err
+	}
+
+	if _, err := r.writer.Write(frame.Bytes()); err != nil {
+		return err
+	}
+
+	// make sure the telegram ends up in the file, even when compressing
+	if r.gzip != nil {
+		if err := r.gzip.Flush(); err != nil {
+			return err
+		}
+	}
+
+	info, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	r.size = info.Size()
+
+	return nil
+}
+
+// Close closes the capture file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+
+	return r.close()
+}
+
+func (r *Recorder) open() error {
+	file, err := os.OpenFile(r.config.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec // captures aren't secret
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	r.file = file
+	r.writer = file
+	r.size = info.Size()
+	r.opened = r.nowFunc()
+
+	// appending to a compressed file adds a gzip member, which is fine
+	if r.config.Gzip {
+		r.gzip = gzip.NewWriter(file)
+		r.writer = r.gzip
+	}
+
+	return nil
+}
+
+func (r *Recorder) close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	var err error
+	if r.gzip != nil {
+		err = r.gzip.Close()
+		r.gzip = nil
+	}
+
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	r.file = nil
+
+	return err
+}
+
+func (r *Recorder) shouldRotate() bool {
+	maxSize := r.config.MaxSize
+	if r.retrySize > 0 {
+		maxSize = r.retrySize
+	}
+
+	if maxSize > 0 && r.size >= maxSize {
+		return true
+	}
+
+	interval := time.Millisecond * time.Duration(r.config.RotateInterval)
+
+	return interval > 0 && r.nowFunc().Sub(r.opened) >= interval
+}
+
+// rotate moves the current capture file aside and starts a new one
+func (r *Recorder) rotate() error {
+	err := r.moveAside()
+	r.retrySize = 0
+
+	// keep recording to the current capture file when it can't be moved aside
+	if openErr := r.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+
+	// reopening restarted RotateInterval, MaxSize is pushed back as well
+	if err != nil {
+		if r.config.MaxSize > 0 {
+			r.retrySize = r.size + r.config.MaxSize
+		}
+
+		return fmt.Errorf("rotating capture file: %w", err)
+	}
+
+	return nil
+}
+
+// moveAside closes the current capture file and renames it
+func (r *Recorder) moveAside() error {
+	if err := r.close(); err != nil {
+		return err
+	}
+
+	rotated, err := rotatedPath(r.config.Path, r.nowFunc())
+	if err != nil {
+		return err
+	}
+
+	return r.renameFunc(r.config.Path, rotated)
+}
+
+// rotatedPath inserts given time before the extension of given path, followed
+// by the first sequence number for which no file exists yet if needed
+func rotatedPath(path string, now time.Time) (string, error) {
+	dir, base := filepath.Split(path)
+
+	name, ext := base, ""
+	if i := strings.IndexByte(base, '.'); i > 0 {
+		name, ext = base[:i], base[i:]
+	}
+
+	name += "-" + now.Format(recorderRotateStamp)
+
+	for seq := 0; ; seq++ {
+		rotated := filepath.Join(dir, name+ext)
+		if seq > 0 {
+			rotated = filepath.Join(dir, name+"-"+strconv.Itoa(seq)+ext)
+		}
+
+		_, err := os.Lstat(rotated)
+		if errors.Is(err, os.ErrNotExist) {
+			return rotated, nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+	}
+}
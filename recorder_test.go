@@ -0,0 +1,241 @@
+package gop1
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	valid, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	invalid, err := os.ReadFile("testdata/crc/invalid")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		gzip bool
+	}{
+		{"plain", false},
+		{"gzip", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "capture.txt")
+			recorder, err := NewRecorder(RecorderConfig{Path: path, Gzip: test.gzip})
+			require.NoError(t, err)
+
+			// garbage in between telegrams isn't recorded
+			input := bytes.Join([][]byte{valid, []byte("garbage\r\n"), invalid}, nil)
+
+			p1 := newP1(bytes.NewReader(input), P1Config{Recorder: recorder})
+			p1.Start(t.Context())
+
+			recorded := 0
+			for range p1.Incoming {
+				recorded++
+			}
+
+			require.Equal(t, 2, recorded)
+
+			require.NoError(t, recorder.Close())
+
+			// the recording replays the same telegrams
+			p1, err = NewFromTransport(&ReplayTransport{Path: path, Loop: true}, P1Config{})
+			require.NoError(t, err)
+			p1.Start(t.Context())
+
+			var statuses []CRCStatus
+
+			for tgram := range p1.Incoming {
+				statuses = append(statuses, tgram.CRCStatus)
+				if len(statuses) == 4 {
+					require.NoError(t, p1.Close())
+				}
+			}
+
+			assert.Equal(t, []CRCStatus{CRCValid, CRCInvalid, CRCValid, CRCInvalid}, statuses)
+
+			if !test.gzip {
+				recording, err := os.ReadFile(path)
+				require.NoError(t, err)
+
+				lines := strings.Split(string(recording), "\r\n")
+				assert.Regexp(t, `^# received \S+ crc valid$`, lines[0])
+				assert.Equal(t, strings.TrimSuffix(string(valid), "\r\n"), strings.Join(lines[1:len(lines)/2], "\r\n"))
+				assert.Regexp(t, `^# received \S+ crc invalid$`, lines[len(lines)/2])
+			}
+		})
+	}
+}
+
+func TestRecorderRotate(t *testing.T) {
+	t.Parallel()
+
+	frame := &Frame{Data: []byte("/ISk5\\2MT382-1000\r\n\r\n1-3:0.2.8(50)\r\n!"), Checksum: "1234"}
+	received := time.Date(2020, 12, 9, 11, 30, 20, 0, time.UTC)
+
+	t.Run("size", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		recorder, err := NewRecorder(RecorderConfig{Path: filepath.Join(dir, "capture.txt"), MaxSize: 1})
+		require.NoError(t, err)
+
+		now := received
+		recorder.nowFunc = func() time.Time { return now }
+
+		for range 3 {
+			require.NoError(t, recorder.Record(frame, received))
+			now = now.Add(time.Second)
+		}
+
+		require.NoError(t, recorder.Close())
+
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "capture-20201209T113021.txt"),
+			filepath.Join(dir, "capture-20201209T113022.txt"),
+			filepath.Join(dir, "capture.txt"),
+		}, files)
+	})
+
+	t.Run("same second", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		recorder, err := NewRecorder(RecorderConfig{Path: filepath.Join(dir, "capture.txt"), MaxSize: 1})
+		require.NoError(t, err)
+
+		recorder.nowFunc = func() time.Time { return received }
+
+		for range 3 {
+			require.NoError(t, recorder.Record(frame, received))
+		}
+
+		require.NoError(t, recorder.Close())
+
+		// no rotated file is overwritten
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "capture-20201209T113020-1.txt"),
+			filepath.Join(dir, "capture-20201209T113020.txt"),
+			filepath.Join(dir, "capture.txt"),
+		}, files)
+	})
+
+	t.Run("rename failure", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "capture.txt")
+		recorder, err := NewRecorder(RecorderConfig{Path: path})
+		require.NoError(t, err)
+
+		recorder.nowFunc = func() time.Time { return received }
+
+		renameErr := errors.New("operation not permitted")
+		recorder.renameFunc = func(string, string) error { return renameErr }
+
+		// rotate after every two telegrams
+		require.NoError(t, recorder.Record(frame, received))
+		recorder.config.MaxSize = 2 * recorder.size
+
+		// rotating keeps failing, but is only tried again after another
+		// MaxSize bytes, while every telegram is recorded
+		var failed []int
+
+		for i := 2; i <= 6; i++ {
+			if err := recorder.Record(frame, received); err != nil {
+				require.ErrorIs(t, err, renameErr)
+				failed = append(failed, i)
+			}
+		}
+
+		assert.Equal(t, []int{3, 5}, failed)
+
+		recording, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, 6, strings.Count(string(recording), string(frame.Bytes())))
+
+		// until rotating succeeds again
+		recorder.renameFunc = os.Rename
+		require.NoError(t, recorder.Record(frame, received))
+		require.NoError(t, recorder.Close())
+
+		recording, err = os.ReadFile(filepath.Join(dir, "capture-20201209T113020.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, 6, strings.Count(string(recording), string(frame.Bytes())))
+
+		recording, err = os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(string(recording), string(frame.Bytes())))
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		recorder, err := NewRecorder(RecorderConfig{Path: filepath.Join(dir, "capture.txt.gz"), RotateInterval: 60000, Gzip: true})
+		require.NoError(t, err)
+
+		now := received
+		recorder.nowFunc = func() time.Time { return now }
+		recorder.opened = now
+
+		for range 3 {
+			require.NoError(t, recorder.Record(frame, received))
+			now = now.Add(30 * time.Second)
+		}
+
+		// the size is the compressed size on disk
+		info, err := os.Stat(filepath.Join(dir, "capture.txt.gz"))
+		require.NoError(t, err)
+		assert.Equal(t, info.Size(), recorder.size)
+
+		require.NoError(t, recorder.Close())
+
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			filepath.Join(dir, "capture-20201209T113120.txt.gz"),
+			filepath.Join(dir, "capture.txt.gz"),
+		}, files)
+
+		// rotated files replay on their own
+		p1, err := NewFromTransport(&ReplayTransport{Path: files[0]}, P1Config{})
+		require.NoError(t, err)
+		p1.Start(t.Context())
+
+		var telegrams int
+		for range p1.Incoming {
+			telegrams++
+		}
+
+		assert.Equal(t, 2, telegrams)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, err := NewRecorder(RecorderConfig{Path: filepath.Join(t.TempDir(), "capture.txt")})
+		require.NoError(t, err)
+		require.NoError(t, recorder.Close())
+		require.ErrorIs(t, recorder.Record(frame, received), os.ErrClosed)
+	})
+}
@@ -0,0 +1,151 @@
+package gop1
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrUnexpectedUnit is returned for telegram lines with a value in another unit
+// than registered for its OBIS code
+var ErrUnexpectedUnit = errors.New("unexpected unit")
+
+// defaultRegistry is the registry used when parsing without a custom registry
+var defaultRegistry = NewOBISRegistry()
+
+// ValueDecoder decodes the values of a telegram line, as found between its
+// parentheses, into telegram values
+type ValueDecoder func(values []string) ([]TelegramValue, error)
+
+// OBISOption configures an OBIS code when registering it
+type OBISOption func(*obisEntry)
+
+// WithUnit makes lines with given OBIS code fail to parse with
+// ErrUnexpectedUnit when one of their values has another unit. Values without
+// a unit are accepted.
+func WithUnit(unit string) OBISOption {
+	return func(e *obisEntry) {
+		e.unit = unit
+	}
+}
+
+// WithDecoder decodes the values of lines with given OBIS code with given
+// decoder, instead of splitting them into a value and an optional unit
+func WithDecoder(decoder ValueDecoder) OBISOption {
+	return func(e *obisEntry) {
+		e.decoder = decoder
+	}
+}
+
+// obisEntry is an OBIS code or pattern registered in an OBISRegistry
+type obisEntry struct {
+	code     OBISCode
+	obisType OBISType
+	unit     string
+	decoder  ValueDecoder
+}
+
+// OBISRegistry maps the OBIS codes of telegram lines to the type of their
+// objects. It is safe for concurrent use.
+type OBISRegistry struct {
+	mu        sync.RWMutex
+	exact     map[OBISCode]*obisEntry
+	wildcards []*obisEntry
+}
+
+// NewOBISRegistry returns a registry with all OBIS codes supported by this
+// library, to which custom codes can be added without affecting the global
+// registry
+func NewOBISRegistry() *OBISRegistry {
+	registry := &OBISRegistry{
+		exact: make(map[OBISCode]*obisEntry),
+	}
+
+	for code, obisType := range allOBISTypes {
+		if err := registry.Register(code, obisType); err != nil {
+			panic(err)
+		}
+	}
+
+	for pattern, obisType := range addOBISTypes {
+		if err := registry.Register(pattern, obisType); err != nil {
+			panic(err)
+		}
+	}
+
+	return registry
+}
+
+// RegisterOBIS adds an OBIS code to the global registry, used by P1,
+// ParseTelegram and Decoder unless configured otherwise. See
+// OBISRegistry.Register.
+func RegisterOBIS(pattern string, t OBISType, opts ...OBISOption) error {
+	return defaultRegistry.Register(pattern, t, opts...)
+}
+
+// Register adds an OBIS code to the registry. The pattern is either an exact
+// OBIS reduced ID, like 1-0:1.8.1, or has wildcards for one or more of its
+// groups, like 0-*:24.2.1 for all M-Bus channels. Exact codes take precedence
+// over wildcards and codes registered later take precedence over earlier ones,
+// so supported codes can be overridden as well.
+func (r *OBISRegistry) Register(pattern string, t OBISType, opts ...OBISOption) error {
+	code, err := ParseOBIS(pattern)
+	if err != nil {
+		return err
+	}
+
+	entry := &obisEntry{code: code, obisType: t}
+	for _, opt := range opts {
+		opt(entry)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !code.IsWildcard() {
+		r.exact[code] = entry
+
+		return nil
+	}
+
+	r.wildcards = append(r.wildcards, entry)
+
+	return nil
+}
+
+// ParseTelegram parses a single telegram like the package level ParseTelegram,
+// using this registry
+func (r *OBISRegistry) ParseTelegram(data []byte) (*Telegram, error) {
+	frame, err := nextFrame(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.parseFrame(frame)
+}
+
+// NewDecoder returns a Decoder reading from given reader, using this registry
+func (r *OBISRegistry) NewDecoder(reader io.Reader) *Decoder {
+	decoder := NewDecoder(reader)
+	decoder.registry = r
+
+	return decoder
+}
+
+// lookup returns the entry for given OBIS code, if any
+func (r *OBISRegistry) lookup(code OBISCode) (*obisEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if entry, ok := r.exact[code]; ok {
+		return entry, true
+	}
+
+	for i := len(r.wildcards) - 1; i >= 0; i-- {
+		if r.wildcards[i].code.Match(code) {
+			return r.wildcards[i], true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,174 @@
+package gop1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	obisTypeMaximumDemand   = "Maximum demand of the current month"
+	obisTypeReactiveEnergy  = "Reactive energy delivered"
+	obisTypeCustomerMessage = "Customer message"
+)
+
+func TestOBISRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewOBISRegistry()
+	require.NoError(t, registry.Register("1-0:1.6.0", obisTypeMaximumDemand, WithUnit("kW")))
+	require.NoError(t, registry.Register("1-*:3.8.*", obisTypeReactiveEnergy, WithUnit("kvarh")))
+	require.NoError(t, registry.Register("0-0:96.13.0", obisTypeCustomerMessage, WithDecoder(
+		func(values []string) ([]TelegramValue, error) {
+			text, err := hex.DecodeString(values[0])
+
+			return []TelegramValue{{Value: string(text)}}, err
+		})))
+
+	// override a wildcard for a single channel
+	require.NoError(t, registry.Register("0-2:24.2.1", OBISTypeDeviceType))
+
+	tests := []struct {
+		line     string
+		obisType OBISType
+		values   []TelegramValue
+		err      error
+	}{
+		{
+			line:     "1-0:1.6.0(200509134558S)(01.111*kW)",
+			obisType: obisTypeMaximumDemand,
+			values:   []TelegramValue{{Value: "200509134558S"}, {"01.111", "kW"}},
+		},
+		{
+			line: "1-0:1.6.0(200509134558S)(01.111*kWh)",
+			err:  ErrUnexpectedUnit,
+		},
+		{
+			line:     "1-0:3.8.0(00000012.345*kvarh)",
+			obisType: obisTypeReactiveEnergy,
+			values:   []TelegramValue{{"00000012.345", "kvarh"}},
+		},
+		{
+			line:     "1-1:3.8.2(00000012.345*kvarh)",
+			obisType: obisTypeReactiveEnergy,
+			values:   []TelegramValue{{"00000012.345", "kvarh"}},
+		},
+		{
+			line:     "0-0:96.13.0(48656C6C6F)",
+			obisType: obisTypeCustomerMessage,
+			values:   []TelegramValue{{Value: "Hello"}},
+		},
+		{
+			line: "0-0:96.13.0(XYZ)",
+			err:  hex.InvalidByteError('X'),
+		},
+		{
+			line:     "0-1:24.2.1(101209112500W)(12785.123*m3)",
+			obisType: OBISTypeGasDelivered,
+			values:   []TelegramValue{{Value: "101209112500W"}, {"12785.123", "m3"}},
+		},
+		{
+			line:     "0-2:24.2.1(101209112500W)(12785.123*m3)",
+			obisType: OBISTypeDeviceType,
+			values:   []TelegramValue{{Value: "101209112500W"}, {"12785.123", "m3"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := registry.parseTelegramLine(test.line)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.obisType, obj.Type)
+			assert.Equal(t, test.values, obj.Values)
+
+			// the global registry isn't affected
+			obj, err = defaultRegistry.parseTelegramLine(test.line)
+			if err != nil {
+				require.ErrorIs(t, err, ErrUnknownOBIS)
+			}
+
+			assert.NotEqual(t, OBISType(obisTypeCustomerMessage), obj.Type)
+		})
+	}
+}
+
+func TestOBISRegistryInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	registry := NewOBISRegistry()
+
+	for _, pattern := range []string{"", "1-0:1.8", "1-0:1.8.1.2", "1-?:1.8.1", "1-0:1.8.1*"} {
+		require.ErrorIs(t, registry.Register(pattern, OBISTypeUnknown), ErrInvalidOBISCode, pattern)
+	}
+}
+
+func TestOBISRegistryParse(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/parser/output0")
+	require.NoError(t, err)
+
+	registry := NewOBISRegistry()
+	require.NoError(t, registry.Register("0-0:96.13.0", obisTypeCustomerMessage))
+
+	tgram, err := registry.ParseTelegram(fixture)
+	require.NoError(t, err)
+	assert.Len(t, tgram.Objects, 35)
+
+	assert.Equal(t, 1, countType(tgram, obisTypeCustomerMessage))
+
+	tgram, err = registry.NewDecoder(bytes.NewReader(fixture)).Decode()
+	require.NoError(t, err)
+	assert.Equal(t, 1, countType(tgram, obisTypeCustomerMessage))
+
+	p1 := NewFromReader(io.NopCloser(bytes.NewReader(fixture)), P1Config{Registry: registry})
+	p1.Start(t.Context())
+
+	tgram = <-p1.Incoming
+	require.NotNil(t, tgram)
+	assert.Equal(t, 1, countType(tgram, obisTypeCustomerMessage))
+	require.NoError(t, p1.Close())
+
+	// parsing with the global registry still uses the supported type
+	tgram, err = ParseTelegram(fixture)
+	require.NoError(t, err)
+	assert.Equal(t, 0, countType(tgram, obisTypeCustomerMessage))
+	assert.Equal(t, 1, countType(tgram, OBISTypeTextMessage))
+}
+
+// countType returns the number of objects of given type in given telegram
+func countType(tgram *Telegram, obisType OBISType) int {
+	count := 0
+
+	for _, obj := range tgram.Objects {
+		if obj.Type == obisType {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestRegisterOBIS(t *testing.T) {
+	t.Parallel()
+
+	// use a code no other test uses, as this changes the global registry
+	require.NoError(t, RegisterOBIS("0-0:96.99.*", obisTypeCustomerMessage))
+
+	tgram, err := ParseTelegram([]byte("/ISk5\\2MT382-1000\r\n\r\n0-0:96.99.1(1)\r\n!\r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, OBISType(obisTypeCustomerMessage), tgram.Objects[0].Type)
+}
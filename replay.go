@@ -0,0 +1,191 @@
+package gop1
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// gzipMagic are the first bytes of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ErrReplayNotSeekable is returned when looping a replay from a source that
+// can't be rewound, like stdin
+var ErrReplayNotSeekable = errors.New("replay source can't be rewound")
+
+// ReplayPacing determines how fast a capture is replayed
+type ReplayPacing int
+
+// These are the ways a capture can be paced
+const (
+	// ReplayUnpaced replays telegrams as fast as they are consumed
+	ReplayUnpaced ReplayPacing = iota
+	// ReplayTimestamps replays telegrams with the time in between them as
+	// recorded by the meter in the 0-0:1.0.0 timestamp
+	ReplayTimestamps
+	// ReplayInterval replays telegrams with a fixed interval
+	ReplayInterval
+)
+
+// ReplayTransport is the Transport replaying telegrams from a capture of raw
+// serial output, read from a file or stdin. Captures written by Recorder,
+// compressed or not, can be replayed as well. Reading ends at the end of the
+// capture, unless it is looped.
+type ReplayTransport struct {
+	// Path is the capture file to replay, stdin is used when it is - or empty
+	Path     string
+	Pacing   ReplayPacing
+	Interval int // in milliseconds, when pacing with ReplayInterval
+	// Speed is the multiplier of the pace, so 60 replays an hour of telegrams
+	// in a minute. It defaults to 1.
+	Speed float64
+	// Loop makes the capture start over when the end is reached
+	Loop bool
+}
+
+// Open opens the capture and starts replaying it
+func (t *ReplayTransport) Open(context.Context) (io.ReadCloser, error) {
+	source := io.ReadCloser(io.NopCloser(os.Stdin))
+
+	if t.Path != "" && t.Path != "-" {
+		file, err := os.Open(t.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		source = file
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, writer := io.Pipe()
+
+	go func() {
+		writer.CloseWithError(t.replay(ctx, source, writer))
+		_ = source.Close()
+	}()
+
+	return &pipeReader{PipeReader: reader, cancel: cancel}, nil
+}
+
+// Name returns the path of the capture
+func (t *ReplayTransport) Name() string {
+	if t.Path == "" {
+		return "-"
+	}
+
+	return t.Path
+}
+
+// replay writes the telegrams read from given source to given writer at the
+// configured pace
+func (t *ReplayTransport) replay(ctx context.Context, source io.Reader, writer io.Writer) error {
+	reader, err := captureReader(source)
+	if err != nil {
+		return err
+	}
+
+	framer := NewFramer(reader, 0)
+	replayed := false
+
+	var previous time.Time
+
+	for {
+		frame, err := framer.Next()
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrTelegramTooLarge):
+				continue
+			case !errors.Is(err, io.EOF):
+				return err
+			case !t.Loop || !replayed:
+				return nil
+			}
+
+			seeker, ok := source.(io.Seeker)
+			if !ok {
+				return ErrReplayNotSeekable
+			}
+
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("%w: %w", ErrReplayNotSeekable, err)
+			}
+
+			if reader, err = captureReader(source); err != nil {
+				return err
+			}
+
+			framer = NewFramer(reader, 0)
+			replayed = false
+
+			continue
+		}
+
+		var delay time.Duration
+
+		delay, previous = t.delay(frame, previous, replayed)
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+
+		if _, err := writer.Write(frame.Bytes()); err != nil {
+			return err
+		}
+
+		replayed = true
+	}
+}
+
+// captureReader returns a reader for given capture, which is decompressed when
+// it was compressed by Recorder
+func captureReader(source io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(source)
+
+	magic, err := buffered.Peek(len(gzipMagic))
+	if err == nil && bytes.Equal(magic, gzipMagic) {
+		return gzip.NewReader(buffered)
+	}
+
+	return buffered, nil
+}
+
+// delay returns how long to wait before replaying given frame, given the
+// timestamp of the previous telegram and whether this is the first telegram
+// of the capture. It also returns the timestamp of given frame.
+func (t *ReplayTransport) delay(frame *Frame, previous time.Time, replayed bool) (time.Duration, time.Time) {
+	speed := t.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	switch t.Pacing {
+	case ReplayInterval:
+		if !replayed {
+			return 0, previous
+		}
+
+		return time.Duration(float64(time.Millisecond) * float64(t.Interval) / speed), previous
+	case ReplayTimestamps:
+		tgram, _ := defaultRegistry.parseFrame(frame)
+
+		timestamp, err := tgram.Timestamp()
+		if err != nil {
+			return 0, previous
+		}
+
+		// the timestamp jumps back when the capture starts over
+		if !replayed || previous.IsZero() || timestamp.Before(previous) {
+			return 0, timestamp
+		}
+
+		return time.Duration(float64(timestamp.Sub(previous)) / speed), timestamp
+	case ReplayUnpaced:
+	}
+
+	return 0, previous
+}
@@ -0,0 +1,115 @@
+package gop1
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCapture writes a capture with a telegram for each of given timestamps
+func writeCapture(t *testing.T, timestamps ...string) string {
+	t.Helper()
+
+	var capture strings.Builder
+	for _, timestamp := range timestamps {
+		capture.WriteString(timestampedTelegram(t, timestamp))
+	}
+
+	path := filepath.Join(t.TempDir(), "capture")
+	require.NoError(t, os.WriteFile(path, []byte(capture.String()), 0o600))
+
+	return path
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	path := writeCapture(t, "101209113020W", "101209113021W", "101209113023W")
+
+	tests := []struct {
+		name      string
+		transport *ReplayTransport
+		telegrams int
+		minimum   time.Duration
+	}{
+		{
+			name:      "unpaced",
+			transport: &ReplayTransport{Path: path},
+			telegrams: 3,
+		},
+		{
+			name:      "timestamps",
+			transport: &ReplayTransport{Path: path, Pacing: ReplayTimestamps, Speed: 50},
+			telegrams: 3,
+			minimum:   60 * time.Millisecond,
+		},
+		{
+			name:      "interval",
+			transport: &ReplayTransport{Path: path, Pacing: ReplayInterval, Interval: 40, Speed: 2},
+			telegrams: 3,
+			minimum:   40 * time.Millisecond,
+		},
+		{
+			name:      "loop",
+			transport: &ReplayTransport{Path: path, Loop: true},
+			telegrams: 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			start := time.Now()
+
+			p1, err := NewFromTransport(test.transport, P1Config{})
+			require.NoError(t, err)
+			p1.Start(t.Context())
+
+			var timestamps []string
+
+			for tgram := range p1.Incoming {
+				timestamps = append(timestamps, tgram.Objects[2].Values[0].Value)
+				if len(timestamps) == test.telegrams {
+					require.NoError(t, p1.Close())
+				}
+			}
+
+			require.Len(t, timestamps, test.telegrams)
+			assert.Equal(t, []string{"101209113020W", "101209113021W", "101209113023W"}, timestamps[:3])
+			assert.GreaterOrEqual(t, time.Since(start), test.minimum)
+
+			if test.transport.Loop {
+				assert.Equal(t, "101209113020W", timestamps[3])
+			}
+		})
+	}
+}
+
+func TestReplayEmptyLoop(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "capture")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	// looping a capture without telegrams should just end
+	p1, err := NewFromTransport(&ReplayTransport{Path: path, Loop: true}, P1Config{})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	_, ok := <-p1.Incoming
+	assert.False(t, ok)
+	require.NoError(t, p1.Err())
+}
+
+func TestReplayMissing(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFromTransport(&ReplayTransport{Path: filepath.Join(t.TempDir(), "missing")}, P1Config{})
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
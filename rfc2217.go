@@ -0,0 +1,253 @@
+package gop1
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// Telnet commands and options, as described in RFC 854 and RFC 2217
+const (
+	telnetIAC  = 255
+	telnetDONT = 254
+	telnetDO   = 253
+	telnetWONT = 252
+	telnetWILL = 251
+	telnetSB   = 250
+	telnetSE   = 240
+
+	telnetOptionBinary  = 0
+	telnetOptionSGA     = 3
+	telnetOptionComPort = 44
+
+	comPortSetBaudrate = 1
+	comPortSetDataSize = 2
+	comPortSetParity   = 3
+	comPortSetStopSize = 4
+
+	comPortParityNone = 1
+	comPortParityOdd  = 2
+	comPortParityEven = 3
+)
+
+// RFC2217Transport is the Transport for a serial device exposed by a remote
+// serial server implementing the Telnet COM port control option from RFC 2217.
+// The serial line settings are negotiated with the server on every connect.
+// Unlike NewRFC2217, NewFromTransport only reconnects when P1Config.Reconnect
+// is set.
+type RFC2217Transport struct {
+	Address     string
+	Serial      SerialSettings
+	DialTimeout int // in milliseconds
+	KeepAlive   int // in milliseconds
+}
+
+// NewRFC2217 returns a P1 object reading from the serial device served on given
+// address by an RFC 2217 serial server, using the serial line settings,
+// DialTimeout and KeepAlive in given configuration. The connection is
+// reestablished whenever it is lost.
+func NewRFC2217(address string, config P1Config) (*P1, error) {
+	config = serialDefaults(config)
+	config.Reconnect = true
+
+	return NewFromTransport(&RFC2217Transport{
+		Address:     address,
+		Serial:      config.Serial(),
+		DialTimeout: config.DialTimeout,
+		KeepAlive:   config.KeepAlive,
+	}, config)
+}
+
+// Open connects to the serial server and configures the serial line
+func (t *RFC2217Transport) Open(ctx context.Context) (io.ReadCloser, error) {
+	tcp := &TCPTransport{
+		Address:     t.Address,
+		DialTimeout: t.DialTimeout,
+		KeepAlive:   t.KeepAlive,
+	}
+
+	conn, err := tcp.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	telnet := newTelnetConn(conn)
+	if err := telnet.configure(t.Serial); err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	return telnet, nil
+}
+
+// Name returns the address of the serial server
+func (t *RFC2217Transport) Name() string {
+	return t.Address
+}
+
+// telnetState is the state of the parser of the Telnet data stream
+type telnetState int
+
+const (
+	telnetStateData telnetState = iota
+	telnetStateIAC
+	telnetStateNegotiation
+	telnetStateSubnegotiation
+	telnetStateSubnegotiationIAC
+)
+
+// telnetConn strips Telnet commands from the data read from the connection and
+// unescapes data bytes that were escaped as IAC IAC
+type telnetConn struct {
+	conn    io.ReadWriteCloser
+	state   telnetState
+	command byte
+	writeMu sync.Mutex
+}
+
+func newTelnetConn(conn io.ReadWriteCloser) *telnetConn {
+	return &telnetConn{conn: conn}
+}
+
+// configure announces the COM port control option and sets the serial line
+// settings
+func (c *telnetConn) configure(settings SerialSettings) error {
+	parity := byte(comPortParityNone)
+
+	switch settings.Parity {
+	case ParityOdd:
+		parity = comPortParityOdd
+	case ParityEven:
+		parity = comPortParityEven
+	case ParityNone:
+	}
+
+	baudrate := make([]byte, 4)
+	binary.BigEndian.PutUint32(baudrate, uint32(settings.Baudrate)) //nolint:gosec // baud rates are positive
+
+	msg := []byte{
+		telnetIAC, telnetWILL, telnetOptionComPort,
+		telnetIAC, telnetWILL, telnetOptionBinary,
+		telnetIAC, telnetDO, telnetOptionBinary,
+		telnetIAC, telnetDO, telnetOptionSGA,
+	}
+	msg = append(msg, subnegotiation(comPortSetBaudrate, baudrate...)...)
+	msg = append(msg, subnegotiation(comPortSetDataSize, byte(settings.DataBits))...) //nolint:gosec // at most 8
+	msg = append(msg, subnegotiation(comPortSetParity, parity)...)
+	msg = append(msg, subnegotiation(comPortSetStopSize, byte(settings.StopBits))...) //nolint:gosec // at most 2
+
+	return c.write(msg)
+}
+
+// subnegotiation returns the COM port control subnegotiation for given command,
+// escaping IAC bytes in its value
+func subnegotiation(command byte, value ...byte) []byte {
+	msg := []byte{telnetIAC, telnetSB, telnetOptionComPort, command}
+
+	for _, b := range value {
+		msg = append(msg, b)
+		if b == telnetIAC {
+			msg = append(msg, telnetIAC)
+		}
+	}
+
+	return append(msg, telnetIAC, telnetSE)
+}
+
+// Read reads data from the connection, leaving out Telnet commands
+func (c *telnetConn) Read(p []byte) (int, error) {
+	for {
+		n, err := c.conn.Read(p)
+		n = c.filter(p[:n])
+
+		// only return without data when the connection failed, since returning
+		// 0 bytes and no error is discouraged
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// filter strips Telnet commands from given data in place and returns the
+// remaining length
+func (c *telnetConn) filter(data []byte) int {
+	n := 0
+
+	for _, b := range data {
+		switch c.state {
+		case telnetStateData:
+			if b == telnetIAC {
+				c.state = telnetStateIAC
+
+				continue
+			}
+
+			data[n] = b
+			n++
+		case telnetStateIAC:
+			switch b {
+			case telnetIAC:
+				// escaped data byte
+				data[n] = b
+				n++
+				c.state = telnetStateData
+			case telnetDO, telnetDONT, telnetWILL, telnetWONT:
+				c.command = b
+				c.state = telnetStateNegotiation
+			case telnetSB:
+				c.state = telnetStateSubnegotiation
+			default:
+				// other commands have no arguments
+				c.state = telnetStateData
+			}
+		case telnetStateNegotiation:
+			c.negotiate(c.command, b)
+			c.state = telnetStateData
+		case telnetStateSubnegotiation:
+			// the server's responses to our COM port settings and notifications
+			// are of no interest
+			if b == telnetIAC {
+				c.state = telnetStateSubnegotiationIAC
+			}
+		case telnetStateSubnegotiationIAC:
+			if b == telnetSE {
+				c.state = telnetStateData
+			} else {
+				c.state = telnetStateSubnegotiation
+			}
+		}
+	}
+
+	return n
+}
+
+// negotiate refuses options requested by the server other than the ones we
+// asked for ourselves
+func (c *telnetConn) negotiate(command byte, option byte) {
+	switch command {
+	case telnetDO:
+		if option != telnetOptionComPort && option != telnetOptionBinary {
+			_ = c.write([]byte{telnetIAC, telnetWONT, option})
+		}
+	case telnetWILL:
+		if option != telnetOptionBinary && option != telnetOptionSGA {
+			_ = c.write([]byte{telnetIAC, telnetDONT, option})
+		}
+	}
+}
+
+func (c *telnetConn) write(msg []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err := c.conn.Write(msg)
+
+	return err
+}
+
+// Close closes the connection
+func (c *telnetConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,151 @@
+package gop1
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTelnetConn struct {
+	io.Reader
+	bytes.Buffer
+}
+
+func (c *fakeTelnetConn) Read(p []byte) (int, error) {
+	return c.Reader.Read(p)
+}
+
+func (c *fakeTelnetConn) Close() error {
+	return nil
+}
+
+func TestTelnetConn(t *testing.T) {
+	t.Parallel()
+
+	input := []byte{
+		'a', 'b', telnetIAC, telnetIAC, 'c',
+		telnetIAC, 241, // NOP
+		telnetIAC, telnetDO, 24, // terminal type
+		telnetIAC, telnetWILL, 1, // echo
+		telnetIAC, telnetDO, telnetOptionComPort,
+		telnetIAC, telnetSB, telnetOptionComPort, 107, telnetIAC, telnetIAC, telnetIAC, telnetSE,
+		'd',
+	}
+
+	// read byte by byte to cover commands split over reads
+	fake := &fakeTelnetConn{Reader: iotest.OneByteReader(bytes.NewReader(input))}
+	data, err := io.ReadAll(newTelnetConn(fake))
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte{'a', 'b', telnetIAC, 'c', 'd'}, data)
+	assert.Equal(t, []byte{telnetIAC, telnetWONT, 24, telnetIAC, telnetDONT, 1}, fake.Bytes())
+}
+
+func TestSubnegotiation(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t,
+		[]byte{telnetIAC, telnetSB, telnetOptionComPort, comPortSetBaudrate, 0, 0, 0x25, 0x80, telnetIAC, telnetSE},
+		subnegotiation(comPortSetBaudrate, 0, 0, 0x25, 0x80))
+	assert.Equal(t,
+		[]byte{telnetIAC, telnetSB, telnetOptionComPort, comPortSetBaudrate, 0, 0, telnetIAC, telnetIAC, 0, telnetIAC, telnetSE},
+		subnegotiation(comPortSetBaudrate, 0, 0, telnetIAC, 0))
+}
+
+// readSubnegotiations reads from a fake RFC 2217 server's connection until
+// given number of COM port control subnegotiations were received
+func readSubnegotiations(reader *bufio.Reader, count int) (map[byte][]byte, error) {
+	settings := make(map[byte][]byte)
+
+	for len(settings) < count {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b != telnetSB {
+			continue
+		}
+
+		// option, command and value up to IAC SE
+		msg, err := reader.ReadBytes(telnetSE)
+		if err != nil {
+			return nil, err
+		}
+
+		if msg[0] == telnetOptionComPort {
+			settings[msg[1]] = bytes.ReplaceAll(msg[2:len(msg)-2], []byte{telnetIAC, telnetIAC}, []byte{telnetIAC})
+		}
+	}
+
+	return settings, nil
+}
+
+func TestRFC2217(t *testing.T) {
+	t.Parallel()
+
+	testdata, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer listener.Close()
+
+	settings := make(chan map[byte][]byte, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		received, err := readSubnegotiations(bufio.NewReader(conn), 4)
+		if err != nil {
+			close(settings)
+
+			return
+		}
+
+		settings <- received
+
+		// acknowledge the settings halfway the telegram
+		_, _ = conn.Write(testdata[:100])
+		_, _ = conn.Write([]byte{telnetIAC, telnetSB, telnetOptionComPort, 100 + comPortSetBaudrate, 0, 0, 0x25, 0x80, telnetIAC, telnetSE})
+		_, _ = conn.Write(testdata[100:])
+
+		// wait for the listener to be closed
+		_, _ = listener.Accept()
+	}()
+
+	p1, err := NewRFC2217(listener.Addr().String(), P1Config{DialTimeout: 1000, KeepAlive: 2000}.WithSerial(DSMR2Serial))
+	require.NoError(t, err)
+	assert.Equal(t, &RFC2217Transport{
+		Address:     listener.Addr().String(),
+		Serial:      DSMR2Serial,
+		DialTimeout: 1000,
+		KeepAlive:   2000,
+	}, p1.transport)
+	p1.Start(t.Context())
+
+	telegram := <-p1.Incoming
+	assert.Equal(t, CRCValid, telegram.CRCStatus)
+	assert.Len(t, telegram.Objects, 35)
+	require.NoError(t, p1.Close())
+
+	assert.Equal(t, map[byte][]byte{
+		comPortSetBaudrate: {0, 0, 0x25, 0x80},
+		comPortSetDataSize: {7},
+		comPortSetParity:   {comPortParityEven},
+		comPortSetStopSize: {1},
+	}, <-settings)
+}
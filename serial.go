@@ -0,0 +1,100 @@
+package gop1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+const (
+	defaultDataBits = 8
+	defaultStopBits = 1
+)
+
+// Parity is the parity of a serial line
+type Parity byte
+
+// These are the supported parities of a serial line
+const (
+	ParityNone Parity = 'N'
+	ParityOdd  Parity = 'O'
+	ParityEven Parity = 'E'
+)
+
+// SerialSettings are the line settings of a serial device
+type SerialSettings struct {
+	Baudrate int
+	DataBits int
+	Parity   Parity
+	StopBits int
+}
+
+// These are the serial line settings used by the different DSMR versions
+var (
+	// DSMR2Serial are the line settings of DSMR 2.2 and 3.0 meters: 9600 7E1
+	DSMR2Serial = SerialSettings{Baudrate: 9600, DataBits: 7, Parity: ParityEven, StopBits: 1}
+	// DSMR4Serial are the line settings of DSMR 4 and later and e-MUCS
+	// meters: 115200 8N1
+	DSMR4Serial = SerialSettings{Baudrate: 115200, DataBits: 8, Parity: ParityNone, StopBits: 1}
+)
+
+// String returns the settings in the usual notation, like 115200 8N1
+func (s SerialSettings) String() string {
+	return fmt.Sprintf("%d %d%c%d", s.Baudrate, s.DataBits, s.Parity, s.StopBits)
+}
+
+// Serial returns the serial line settings of the configuration
+func (c P1Config) Serial() SerialSettings {
+	return SerialSettings{
+		Baudrate: c.Baudrate,
+		DataBits: c.DataBits,
+		Parity:   c.Parity,
+		StopBits: c.StopBits,
+	}
+}
+
+// WithSerial returns a copy of the configuration with given serial line
+// settings, such as DSMR2Serial
+func (c P1Config) WithSerial(settings SerialSettings) P1Config {
+	c.Baudrate = settings.Baudrate
+	c.DataBits = settings.DataBits
+	c.Parity = settings.Parity
+	c.StopBits = settings.StopBits
+
+	return c
+}
+
+// serialDefaults returns given configuration with defaults for the serial line
+// settings that were not set
+func serialDefaults(config P1Config) P1Config {
+	if config.Baudrate <= 0 {
+		config.Baudrate = defaultBaudrate
+	}
+
+	if config.DataBits <= 0 {
+		config.DataBits = defaultDataBits
+	}
+
+	if config.Parity == 0 {
+		config.Parity = ParityNone
+	}
+
+	if config.StopBits <= 0 {
+		config.StopBits = defaultStopBits
+	}
+
+	return config
+}
+
+// serialConfig returns the configuration to open the serial device with
+func serialConfig(config P1Config) *serial.Config {
+	return &serial.Config{
+		Name:        config.USBDevice,
+		Baud:        config.Baudrate,
+		Size:        byte(config.DataBits), //nolint:gosec // validated by serial.OpenPort
+		Parity:      serial.Parity(config.Parity),
+		StopBits:    serial.StopBits(config.StopBits), //nolint:gosec // validated by serial.OpenPort
+		ReadTimeout: time.Millisecond * time.Duration(config.Timeout),
+	}
+}
@@ -0,0 +1,27 @@
+package gop1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tarm/serial"
+)
+
+func TestSerialSettings(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "9600 7E1", DSMR2Serial.String())
+	assert.Equal(t, "115200 8N1", DSMR4Serial.String())
+
+	config := P1Config{USBDevice: "/dev/ttyUSB0", Timeout: 500}.WithSerial(DSMR2Serial)
+	assert.Equal(t, DSMR2Serial, config.Serial())
+	assert.Equal(t, &serial.Config{
+		Name:        "/dev/ttyUSB0",
+		Baud:        9600,
+		Size:        7,
+		Parity:      serial.ParityEven,
+		StopBits:    serial.Stop1,
+		ReadTimeout: 500 * time.Millisecond,
+	}, serialConfig(config))
+}
@@ -0,0 +1,337 @@
+package gop1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTailInterval = 500
+	// tailChecksumLength is the number of bytes up to the persisted offset that
+	// are checksummed to recognize the file after a restart
+	tailChecksumLength = 64
+)
+
+// TailTransport is the Transport following a capture file that is continuously
+// appended to, like tail -F does. It picks up on the file being rotated or
+// truncated and, when OffsetFile is set, persists how far it got so it can
+// resume from there after a restart.
+type TailTransport struct {
+	Path string
+	// OffsetFile is where the offset in Path after the last telegram is
+	// persisted, once P1 delivered the telegram on Incoming. A telegram that
+	// was not delivered yet is read again after a restart. The offset is
+	// persisted with a checksum of the bytes leading up to it. When the offset
+	// lies beyond the end of the file or those bytes changed, the file is
+	// assumed to be rotated and is read from the start.
+	OffsetFile string
+	// Interval is how often the file is checked for new data once the end is
+	// reached
+	Interval int // in milliseconds
+}
+
+// Open opens the file, at the persisted offset if any, and starts following it
+func (t *TailTransport) Open(context.Context) (io.ReadCloser, error) {
+	file, err := os.Open(t.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := t.resumeOffset(file)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	interval := t.Interval
+	if interval <= 0 {
+		interval = defaultTailInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	follower := &followReader{
+		done:     ctx.Done(),
+		path:     t.Path,
+		interval: time.Millisecond * time.Duration(interval),
+		file:     file,
+		reset:    -offset,
+	}
+
+	reader, writer := io.Pipe()
+	tail := &tailReader{
+		pipeReader: &pipeReader{PipeReader: reader, cancel: cancel},
+		transport:  t,
+	}
+
+	go func() {
+		writer.CloseWithError(t.follow(follower, writer, tail))
+		_ = follower.Close()
+	}()
+
+	return tail, nil
+}
+
+// Name returns the path of the file
+func (t *TailTransport) Name() string {
+	return t.Path
+}
+
+// resumeOffset seeks given file to the persisted offset and returns it
+func (t *TailTransport) resumeOffset(file *os.File) (int64, error) {
+	if t.OffsetFile == "" {
+		return 0, nil
+	}
+
+	data, err := os.ReadFile(t.OffsetFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// a corrupt offset file means starting over
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, nil
+	}
+
+	offset, parseErr := strconv.ParseInt(fields[0], 10, 64)
+	if parseErr != nil || offset < 0 || offset > info.Size() {
+		return 0, nil
+	}
+
+	checksum, err := tailChecksum(file, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	if checksum != fields[1] {
+		return 0, nil
+	}
+
+	return file.Seek(offset, io.SeekStart)
+}
+
+// follow writes the telegrams read from the followed file to given writer and
+// queues the offset after every telegram on given reader, to be persisted once
+// the telegram was delivered
+func (t *TailTransport) follow(follower *followReader, writer io.Writer, tail *tailReader) error {
+	framer := NewFramer(follower, 0)
+
+	for {
+		frame, err := framer.Next()
+		if err != nil {
+			if errors.Is(err, ErrTelegramTooLarge) {
+				continue
+			}
+
+			return err
+		}
+
+		// queued before writing, as P1 might be done with it right away
+		pending := tailOffset{offset: -1}
+		if offset, ok := follower.offset(framer.consumed); ok {
+			// fails when the file was truncated in the meantime
+			if checksum, err := tailChecksum(follower.file, offset); err == nil {
+				pending = tailOffset{offset: offset, checksum: checksum}
+			}
+		}
+
+		tail.queue(pending)
+
+		if _, err := writer.Write(frame.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// tailReader is the reader returned by TailTransport, which persists the
+// offset after a telegram once P1 acknowledges it
+type tailReader struct {
+	*pipeReader
+	transport *TailTransport
+	mu        sync.Mutex
+	// pending are the offsets after the telegrams that were not acknowledged
+	// yet
+	pending []tailOffset
+}
+
+// tailOffset is the offset in the followed file after a telegram, -1 when
+// unknown, with the checksum of the bytes leading up to it
+type tailOffset struct {
+	offset   int64
+	checksum string
+}
+
+// queue adds the offset after the telegram about to be written
+func (r *tailReader) queue(offset tailOffset) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pending = append(r.pending, offset)
+}
+
+// acknowledge persists the offset after the oldest pending telegram
+func (r *tailReader) acknowledge() error {
+	r.mu.Lock()
+
+	if len(r.pending) == 0 {
+		r.mu.Unlock()
+
+		return nil
+	}
+
+	offset := r.pending[0]
+	r.pending = r.pending[1:]
+	r.mu.Unlock()
+
+	if offset.offset < 0 {
+		return nil
+	}
+
+	return r.transport.saveOffset(offset)
+}
+
+// saveOffset atomically replaces the offset file
+func (t *TailTransport) saveOffset(offset tailOffset) error {
+	if t.OffsetFile == "" {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(t.OffsetFile), filepath.Base(t.OffsetFile)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.WriteString(strconv.FormatInt(offset.offset, 10) + " " + offset.checksum + "\n"); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return os.Rename(tmp.Name(), t.OffsetFile)
+}
+
+// tailChecksum returns the checksum of the bytes in given file leading up to
+// given offset
+func tailChecksum(file *os.File, offset int64) (string, error) {
+	start := max(offset-tailChecksumLength, 0)
+	data := make([]byte, offset-start)
+
+	if _, err := file.ReadAt(data, start); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE(data)), nil
+}
+
+// followReader reads a file without ever reaching its end: at the end it waits
+// for data to be appended, the file to be truncated or replaced by a new file
+// at the same path
+type followReader struct {
+	done     <-chan struct{}
+	path     string
+	interval time.Duration
+	file     *os.File
+	// read is the number of bytes returned so far, reset is the value of read
+	// at offset 0 of the current file
+	read  int64
+	reset int64
+}
+
+// Read reads from the file, waiting for more data at the end of the file
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.file.Read(p)
+		r.read += int64(n)
+
+		if n > 0 || !errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		if err := r.reopen(); err != nil {
+			return 0, err
+		}
+
+		select {
+		case <-time.After(r.interval):
+		case <-r.done:
+			return 0, io.ErrClosedPipe
+		}
+	}
+}
+
+// reopen checks whether the file was replaced or truncated, in which case
+// reading starts over at the beginning of the (new) file
+func (r *followReader) reopen() error {
+	current, err := r.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// while the file is being rotated there might be no file at all, in which
+	// case the current file is followed meanwhile
+	if info, err := os.Stat(r.path); err == nil && !os.SameFile(current, info) {
+		if file, err := os.Open(r.path); err == nil {
+			_ = r.file.Close()
+			r.file = file
+			r.reset = r.read
+		}
+
+		return nil
+	}
+
+	position, err := r.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if current.Size() < position {
+		if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		r.reset = r.read
+	}
+
+	return nil
+}
+
+// offset returns the offset in the current file for given number of bytes
+// read, unless those bytes came from a previous file
+func (r *followReader) offset(read int64) (int64, bool) {
+	if read < r.reset {
+		return 0, false
+	}
+
+	return read - r.reset, true
+}
+
+// Close closes the file
+func (r *followReader) Close() error {
+	return r.file.Close()
+}
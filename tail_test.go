@@ -0,0 +1,263 @@
+package gop1
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendFile(t *testing.T, path string, data string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+
+	_, err = file.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
+
+// savedOffset returns the offset persisted in given offset file, -1 when there
+// is none
+func savedOffset(offsetFile string) int64 {
+	data, err := os.ReadFile(offsetFile)
+	if err != nil {
+		return -1
+	}
+
+	offset, err := strconv.ParseInt(strings.Fields(string(data) + " ")[0], 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return offset
+}
+
+func TestTail(t *testing.T) {
+	t.Parallel()
+
+	output1, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	valid, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "p1.log")
+	transport := &TailTransport{
+		Path:       path,
+		OffsetFile: filepath.Join(dir, "p1.offset"),
+		Interval:   5,
+	}
+
+	appendFile(t, path, timestampedTelegram(t, "101209113020W"))
+
+	p1, err := NewFromTransport(transport, P1Config{})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	tgram := <-p1.Incoming
+	assert.Equal(t, "101209113020W", tgram.Objects[2].Values[0].Value)
+
+	// appending
+	appendFile(t, path, timestampedTelegram(t, "101209113021W"))
+
+	tgram = <-p1.Incoming
+	assert.Equal(t, "101209113021W", tgram.Objects[2].Values[0].Value)
+
+	// rotating
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendFile(t, path, string(valid))
+
+	tgram = <-p1.Incoming
+	assert.Equal(t, `ISk5\2MT382-1000`, tgram.Device)
+
+	// truncating, the new telegram is shorter than the old one
+	require.NoError(t, os.Truncate(path, 0))
+	appendFile(t, path, timestampedTelegram(t, "101209113022W"))
+
+	tgram = <-p1.Incoming
+	assert.Equal(t, "101209113022W", tgram.Objects[2].Values[0].Value)
+
+	// the offset is persisted once the telegram was delivered
+	require.Eventually(t, func() bool {
+		return savedOffset(transport.OffsetFile) == int64(len(output1))
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, p1.Close())
+
+	// resuming after the last telegram
+	appendFile(t, path, timestampedTelegram(t, "101209113023W"))
+
+	p1, err = NewFromTransport(transport, P1Config{})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	tgram = <-p1.Incoming
+	assert.Equal(t, "101209113023W", tgram.Objects[2].Values[0].Value)
+
+	// wait for the offset to be persisted before the directory is removed
+	require.Eventually(t, func() bool {
+		return savedOffset(transport.OffsetFile) == int64(2*len(output1))
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, p1.Close())
+}
+
+func TestTailUndelivered(t *testing.T) {
+	t.Parallel()
+
+	output1, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "p1.log")
+	transport := &TailTransport{
+		Path:       path,
+		OffsetFile: filepath.Join(dir, "p1.offset"),
+		Interval:   5,
+	}
+
+	appendFile(t, path, string(output1))
+	appendFile(t, path, timestampedTelegram(t, "101209113021W"))
+
+	p1, err := NewFromTransport(transport, P1Config{})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	tail, ok := p1.currentDevice().(*tailReader)
+	require.True(t, ok)
+
+	pending := func() int {
+		tail.mu.Lock()
+		defer tail.mu.Unlock()
+
+		return len(tail.pending)
+	}
+
+	tgram := <-p1.Incoming
+	assert.Equal(t, "101209113020W", tgram.Objects[2].Values[0].Value)
+
+	// the second telegram is read, but never received from Incoming
+	require.Eventually(t, func() bool {
+		return savedOffset(transport.OffsetFile) == int64(len(output1)) && pending() == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, p1.Close())
+
+	assert.Equal(t, int64(len(output1)), savedOffset(transport.OffsetFile))
+
+	// so it is read again after a restart
+	p1, err = NewFromTransport(transport, P1Config{})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	tgram = <-p1.Incoming
+	assert.Equal(t, "101209113021W", tgram.Objects[2].Values[0].Value)
+
+	require.Eventually(t, func() bool {
+		return savedOffset(transport.OffsetFile) == int64(2*len(output1))
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, p1.Close())
+}
+
+func TestTailRotatedWhileDown(t *testing.T) {
+	t.Parallel()
+
+	output1, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	valid, err := os.ReadFile("testdata/crc/valid")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "p1.log")
+	transport := &TailTransport{
+		Path:       path,
+		OffsetFile: filepath.Join(dir, "p1.offset"),
+		Interval:   5,
+	}
+
+	appendFile(t, path, string(output1))
+
+	p1, err := NewFromTransport(transport, P1Config{})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	<-p1.Incoming
+	require.Eventually(t, func() bool {
+		return savedOffset(transport.OffsetFile) == int64(len(output1))
+	}, time.Second, time.Millisecond)
+	require.NoError(t, p1.Close())
+
+	// rotated while not running, to a file longer than the persisted offset
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendFile(t, path, string(valid)+string(output1))
+	require.Greater(t, len(valid), len(output1))
+
+	p1, err = NewFromTransport(transport, P1Config{})
+	require.NoError(t, err)
+	p1.Start(t.Context())
+
+	// which is read from the start
+	tgram := <-p1.Incoming
+	assert.Equal(t, `ISk5\2MT382-1000`, tgram.Device)
+
+	require.Eventually(t, func() bool {
+		return savedOffset(transport.OffsetFile) == int64(len(valid))
+	}, time.Second, time.Millisecond)
+	require.NoError(t, p1.Close())
+}
+
+func TestTailResumeOffset(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "p1.log")
+	require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0o600))
+
+	checksum := func(data string) string {
+		return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(data)))
+	}
+
+	tests := []struct {
+		offset string
+		result int64
+	}{
+		{"4 " + checksum("0123") + "\n", 4},
+		{"10 " + checksum("0123456789"), 10},
+		{"0 " + checksum(""), 0},
+		{"4 " + checksum("abcd"), 0}, // another file
+		{"4", 0},
+		{"11 " + checksum("0123456789"), 0},
+		{"-1 " + checksum(""), 0},
+		{"foo", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.offset, func(t *testing.T) {
+			t.Parallel()
+
+			offsetFile := filepath.Join(t.TempDir(), "offset")
+			require.NoError(t, os.WriteFile(offsetFile, []byte(test.offset), 0o600))
+
+			file, err := os.Open(path)
+			require.NoError(t, err)
+
+			defer file.Close()
+
+			offset, err := (&TailTransport{Path: path, OffsetFile: offsetFile}).resumeOffset(file)
+			require.NoError(t, err)
+			assert.Equal(t, test.result, offset)
+		})
+	}
+}
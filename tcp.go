@@ -0,0 +1,64 @@
+package gop1
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 5000
+	defaultKeepAlive   = 15000
+)
+
+// TCPTransport is the Transport for a P1 port served over TCP, like by ser2net
+// or a P1-to-Ethernet gateway sending raw telegrams. Unlike NewTCP,
+// NewFromTransport only reconnects when P1Config.Reconnect is set.
+type TCPTransport struct {
+	Address     string
+	DialTimeout int // in milliseconds
+	KeepAlive   int // in milliseconds
+}
+
+// NewTCP returns a P1 object reading from the P1 port served on given TCP
+// address, connecting with the DialTimeout and KeepAlive in given
+// configuration. The connection is reestablished whenever it is lost.
+func NewTCP(address string, config P1Config) (*P1, error) {
+	config.Reconnect = true
+
+	return NewFromTransport(&TCPTransport{
+		Address:     address,
+		DialTimeout: config.DialTimeout,
+		KeepAlive:   config.KeepAlive,
+	}, config)
+}
+
+// Open connects to the TCP address
+func (t *TCPTransport) Open(ctx context.Context) (io.ReadCloser, error) {
+	return t.dial(ctx)
+}
+
+// Name returns the TCP address
+func (t *TCPTransport) Name() string {
+	return t.Address
+}
+
+func (t *TCPTransport) dial(ctx context.Context) (net.Conn, error) {
+	dialTimeout := t.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = defaultDialTimeout
+	}
+
+	keepAlive := t.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = defaultKeepAlive
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   time.Millisecond * time.Duration(dialTimeout),
+		KeepAlive: time.Millisecond * time.Duration(keepAlive),
+	}
+
+	return dialer.DialContext(ctx, "tcp", t.Address)
+}
@@ -0,0 +1,95 @@
+package gop1
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCP(t *testing.T) {
+	t.Parallel()
+
+	output0, err := os.ReadFile("testdata/parser/output0")
+	require.NoError(t, err)
+
+	output1, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer listener.Close()
+
+	// replay both fixtures and hang up, then replay the first one again on the
+	// next connection and keep it open
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		_, _ = conn.Write(output0)
+		_, _ = conn.Write(output1)
+		_ = conn.Close()
+
+		conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		_, _ = conn.Write(output0)
+
+		// wait for the listener to be closed
+		_, _ = listener.Accept()
+	}()
+
+	var events []ConnectionEvent
+
+	p1, err := NewTCP(listener.Addr().String(), P1Config{
+		ReconnectMinDelay: 1,
+		DialTimeout:       1000,
+		KeepAlive:         2000,
+		OnConnectionEvent: func(event ConnectionEvent) {
+			events = append(events, event)
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &TCPTransport{Address: listener.Addr().String(), DialTimeout: 1000, KeepAlive: 2000}, p1.transport)
+	p1.Start(t.Context())
+
+	telegrams := make([]*Telegram, 0)
+	for telegram := range p1.Incoming {
+		telegrams = append(telegrams, telegram)
+		if len(telegrams) == 3 {
+			require.NoError(t, p1.Close())
+		}
+	}
+
+	require.Len(t, telegrams, 3)
+	assert.Equal(t, `ISk5\2MT382-1000`, telegrams[0].Device)
+	assert.Equal(t, `FLU5\493523491_A`, telegrams[1].Device)
+	assert.Equal(t, `ISk5\2MT382-1000`, telegrams[2].Device)
+	require.ErrorIs(t, p1.Err(), ErrClosed)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, StateDisconnected, events[0].State)
+	assert.Equal(t, listener.Addr().String(), events[0].Device)
+	assert.Equal(t, StateReconnecting, events[1].State)
+	assert.Equal(t, StateConnected, events[2].State)
+}
+
+func TestTCPConnectError(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	_, err = NewTCP(listener.Addr().String(), P1Config{})
+	require.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package gop1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	timestampLayout = "060102150405"
+	// meters in the Netherlands and Belgium use the same time zone
+	timestampZone = "Europe/Amsterdam"
+)
+
+var (
+	// timestampLocation is nil when the time zone database is not available,
+	// in which case the EU daylight saving time rules are applied by hand
+	timestampLocation = loadTimestampLocation()
+	// offsets for the DST flag of timestamps, S during DST and W otherwise
+	timestampSummer = time.FixedZone("CEST", 2*60*60)
+	timestampWinter = time.FixedZone("CET", 1*60*60)
+)
+
+// ParseTimestamp parses a timestamp formatted as YYMMDDhhmmssX, as used by
+// meters in the Netherlands and Belgium, into a time in Europe/Amsterdam. X is
+// S during daylight saving time and W otherwise, which tells apart the hour
+// repeated at the end of daylight saving time. Timestamps without the flag,
+// as sent by some older meters, are taken as local time in Europe/Amsterdam.
+// Use time.Time.In for Europe/Brussels, which has the same offsets. Without a
+// time zone database, the time is in the fixed CET or CEST zone instead.
+func ParseTimestamp(value string) (time.Time, error) {
+	return parseTimestamp(value, timestampLocation)
+}
+
+// parseTimestamp parses given timestamp into given location, or into CET or
+// CEST when location is nil
+func parseTimestamp(value string, location *time.Location) (time.Time, error) {
+	var zone *time.Location
+
+	switch {
+	case len(value) == len(timestampLayout) && location != nil:
+		zone = location
+	case len(value) == len(timestampLayout):
+		// tried as winter time first, which time.ParseInLocation picks for
+		// the repeated hour as well
+		zone = timestampWinter
+	case len(value) == len(timestampLayout)+1 && strings.EqualFold(value[len(timestampLayout):], "S"):
+		zone = timestampSummer
+	case len(value) == len(timestampLayout)+1 && strings.EqualFold(value[len(timestampLayout):], "W"):
+		zone = timestampWinter
+	default:
+		return time.Time{}, fmt.Errorf("%w %q: not formatted as YYMMDDhhmmssX", ErrInvalidValue, value)
+	}
+
+	timestamp, err := time.ParseInLocation(timestampLayout, value[:len(timestampLayout)], zone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %q: %w", ErrInvalidValue, value, err)
+	}
+
+	// the same local time in summer time is an hour earlier
+	if len(value) == len(timestampLayout) && location == nil && isSummerTime(timestamp) {
+		timestamp = timestamp.Add(-time.Hour)
+	}
+
+	if location != nil {
+		return timestamp.In(location), nil
+	}
+
+	if isSummerTime(timestamp) {
+		return timestamp.In(timestampSummer), nil
+	}
+
+	return timestamp.In(timestampWinter), nil
+}
+
+// Timestamp returns the time the telegram was sent by the meter, according to
+// its clock
+func (t *Telegram) Timestamp() (time.Time, error) {
+	return t.Time(OBISTypeDateTimestamp)
+}
+
+// loadTimestampLocation returns Europe/Amsterdam, or nil when the time zone
+// database is not available
+func loadTimestampLocation() *time.Location {
+	location, err := time.LoadLocation(timestampZone)
+	if err != nil {
+		return nil
+	}
+
+	return location
+}
+
+// isSummerTime returns whether daylight saving time applies in the EU at given
+// time, which runs from 01:00 UTC on the last Sunday of March up to 01:00 UTC
+// on the last Sunday of October
+func isSummerTime(t time.Time) bool {
+	year := t.UTC().Year()
+
+	return !t.Before(lastSunday(year, time.March).Add(time.Hour)) &&
+		t.Before(lastSunday(year, time.October).Add(time.Hour))
+}
+
+// lastSunday returns midnight UTC of the last Sunday of given month
+func lastSunday(year int, month time.Month) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+
+	return last.AddDate(0, 0, -int(last.Weekday()))
+}
@@ -0,0 +1,114 @@
+package gop1
+
+import (
+	"os"
+	"testing"
+	"time"
+	// the fallback is compared to the time zone database
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	t.Parallel()
+
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	require.NoError(t, err)
+
+	tests := []struct {
+		value  string
+		result time.Time
+		offset int
+	}{
+		{"101209113020W", time.Date(2010, 12, 9, 10, 30, 20, 0, time.UTC), 3600},
+		{"200512134558S", time.Date(2020, 5, 12, 11, 45, 58, 0, time.UTC), 7200},
+		{"200512134558s", time.Date(2020, 5, 12, 11, 45, 58, 0, time.UTC), 7200},
+		// the hour repeated at the end of DST
+		{"201025023000S", time.Date(2020, 10, 25, 0, 30, 0, 0, time.UTC), 7200},
+		{"201025023000W", time.Date(2020, 10, 25, 1, 30, 0, 0, time.UTC), 3600},
+		// without DST flag, local time is assumed
+		{"200512134558", time.Date(2020, 5, 12, 11, 45, 58, 0, time.UTC), 7200},
+		{"101209113020", time.Date(2010, 12, 9, 10, 30, 20, 0, time.UTC), 3600},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			t.Parallel()
+
+			timestamp, err := ParseTimestamp(test.value)
+			require.NoError(t, err)
+			assert.True(t, test.result.Equal(timestamp), timestamp)
+			assert.Equal(t, amsterdam.String(), timestamp.Location().String())
+
+			_, offset := timestamp.Zone()
+			assert.Equal(t, test.offset, offset)
+		})
+	}
+
+	for _, value := range []string{"", "1012091130", "101209113020X", "101309113020W", "10120911302aW"} {
+		_, err := ParseTimestamp(value)
+		require.ErrorIs(t, err, ErrInvalidValue, value)
+	}
+}
+
+func TestParseTimestampWithoutLocation(t *testing.T) {
+	t.Parallel()
+
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	require.NoError(t, err)
+
+	// every hour, including those around the changes to and from DST
+	for hour := time.Date(2015, 1, 1, 0, 30, 0, 0, time.UTC); hour.Year() < 2030; hour = hour.Add(time.Hour) {
+		local := hour.In(amsterdam)
+		flag := "W"
+
+		if local.IsDST() {
+			flag = "S"
+		}
+
+		value := local.Format(timestampLayout)
+
+		timestamp, err := parseTimestamp(value+flag, nil)
+		require.NoError(t, err)
+		require.True(t, hour.Equal(timestamp), value+flag)
+
+		_, offset := timestamp.Zone()
+		_, expected := local.Zone()
+		require.Equal(t, expected, offset, value+flag)
+
+		// without the flag local time is ambiguous, like it is in the time
+		// zone database
+		expectedLocal, err := time.ParseInLocation(timestampLayout, value, amsterdam)
+		require.NoError(t, err)
+		timestamp, err = parseTimestamp(value, nil)
+		require.NoError(t, err)
+		require.True(t, expectedLocal.Equal(timestamp), value)
+	}
+}
+
+func TestTelegramTimestamp(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/parser/output2")
+	require.NoError(t, err)
+
+	tgram, err := ParseTelegram(fixture)
+	require.NoError(t, err)
+
+	timestamp, err := tgram.Timestamp()
+	require.NoError(t, err)
+	assert.True(t, time.Date(2020, 5, 12, 11, 54, 9, 0, time.UTC).Equal(timestamp), timestamp)
+	assert.Equal(t, timestampZone, timestamp.Location().String())
+
+	// no points are lost or duplicated across the end of DST
+	summer, err := ParseTimestamp("201025025959S")
+	require.NoError(t, err)
+	winter, err := ParseTimestamp("201025020000W")
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, winter.Sub(summer))
+
+	_, err = (&Telegram{}).Timestamp()
+	require.ErrorIs(t, err, ErrObjectNotFound)
+}
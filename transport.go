@@ -0,0 +1,53 @@
+package gop1
+
+import (
+	"context"
+	"io"
+
+	"github.com/tarm/serial"
+)
+
+// Transport opens the connection P1 reads telegrams from, such as a serial
+// device or a network connection. When reconnecting, P1 calls Open again.
+type Transport interface {
+	// Open opens a new connection to read telegrams from
+	Open(ctx context.Context) (io.ReadCloser, error)
+	// Name describes the connection, like the name of the serial device
+	Name() string
+}
+
+// serialTransport is the Transport for a local serial device
+type serialTransport struct {
+	config P1Config
+}
+
+// Open opens the serial device
+func (t *serialTransport) Open(context.Context) (io.ReadCloser, error) {
+	return serial.OpenPort(serialConfig(t.config))
+}
+
+// Name returns the name of the serial device
+func (t *serialTransport) Name() string {
+	return t.config.USBDevice
+}
+
+// acknowledger is implemented by readers that need to know when P1 is done with
+// a telegram they returned, because it was delivered or dropped. Telegrams are
+// acknowledged in the order they were read.
+type acknowledger interface {
+	acknowledge() error
+}
+
+// pipeReader is the reading end of a pipe written to by a goroutine, which is
+// stopped by cancelling its context when the reader is closed
+type pipeReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+// Close stops the goroutine writing to the pipe
+func (r *pipeReader) Close() error {
+	r.cancel()
+
+	return r.PipeReader.Close()
+}
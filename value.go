@@ -0,0 +1,125 @@
+package gop1
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrInvalidValue is returned when a telegram value can't be converted to
+	// the requested type
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrObjectNotFound is returned when a telegram has no object of the
+	// requested type
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// Float64 returns the value as a floating point number, like 123456.789 for
+// 123456.789*kWh
+func (v TelegramValue) Float64() (float64, error) {
+	f, err := strconv.ParseFloat(v.Value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: %w", ErrInvalidValue, v.Value, err)
+	}
+
+	return f, nil
+}
+
+// Int returns the value as an integer, like 2 for 0002
+func (v TelegramValue) Int() (int, error) {
+	i, err := strconv.Atoi(v.Value)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: %w", ErrInvalidValue, v.Value, err)
+	}
+
+	return i, nil
+}
+
+// Time returns the value as a time, for values formatted as YYMMDDhhmmssX.
+// See ParseTimestamp.
+func (v TelegramValue) Time() (time.Time, error) {
+	return ParseTimestamp(v.Value)
+}
+
+// Duration returns the value as a duration, for values in seconds like
+// 0000000237*s
+func (v TelegramValue) Duration() (time.Duration, error) {
+	if v.Unit != "" && v.Unit != "s" {
+		return 0, fmt.Errorf("%w %q: %w: %s", ErrInvalidValue, v.Value, ErrUnexpectedUnit, v.Unit)
+	}
+
+	seconds, err := strconv.ParseInt(v.Value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: %w", ErrInvalidValue, v.Value, err)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// Text returns the value as text, for values that are hex encoded like
+// equipment identifiers and text messages
+func (v TelegramValue) Text() (string, error) {
+	text, err := hex.DecodeString(v.Value)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %w", ErrInvalidValue, v.Value, err)
+	}
+
+	return string(text), nil
+}
+
+// Object returns the first object of given type, or nil when the telegram has
+// no object of that type
+func (t *Telegram) Object(obisType OBISType) *TelegramObject {
+	for _, obj := range t.Objects {
+		if obj.Type == obisType && len(obj.Values) > 0 {
+			return obj
+		}
+	}
+
+	return nil
+}
+
+// Float returns the last value of the first object of given type as a floating
+// point number. The last value is the reading for objects that are prefixed
+// by the time they were taken, like gas readings.
+func (t *Telegram) Float(obisType OBISType) (float64, error) {
+	obj := t.Object(obisType)
+	if obj == nil {
+		return 0, fmt.Errorf("%w: %s", ErrObjectNotFound, obisType)
+	}
+
+	return obj.Values[len(obj.Values)-1].Float64()
+}
+
+// Int returns the last value of the first object of given type as an integer
+func (t *Telegram) Int(obisType OBISType) (int, error) {
+	obj := t.Object(obisType)
+	if obj == nil {
+		return 0, fmt.Errorf("%w: %s", ErrObjectNotFound, obisType)
+	}
+
+	return obj.Values[len(obj.Values)-1].Int()
+}
+
+// Time returns the first value of the first object of given type as a time
+func (t *Telegram) Time(obisType OBISType) (time.Time, error) {
+	obj := t.Object(obisType)
+	if obj == nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrObjectNotFound, obisType)
+	}
+
+	return obj.Values[0].Time()
+}
+
+// Text returns the first value of the first object of given type as text
+func (t *Telegram) Text(obisType OBISType) (string, error) {
+	obj := t.Object(obisType)
+	if obj == nil {
+		return "", fmt.Errorf("%w: %s", ErrObjectNotFound, obisType)
+	}
+
+	return obj.Values[0].Text()
+}
@@ -0,0 +1,137 @@
+package gop1
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramValueFloat64(t *testing.T) {
+	t.Parallel()
+
+	f, err := TelegramValue{"123456.789", "kWh"}.Float64()
+	require.NoError(t, err)
+	assert.InDelta(t, 123456.789, f, 0.0001)
+
+	_, err = TelegramValue{Value: "101209113020W"}.Float64()
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestTelegramValueInt(t *testing.T) {
+	t.Parallel()
+
+	i, err := TelegramValue{Value: "0002"}.Int()
+	require.NoError(t, err)
+	assert.Equal(t, 2, i)
+
+	_, err = TelegramValue{Value: "1.5"}.Int()
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestTelegramValueTime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value  string
+		result time.Time
+		err    bool
+	}{
+		{value: "101209113020W", result: time.Date(2010, 12, 9, 10, 30, 20, 0, time.UTC)},
+		{value: "200512134558S", result: time.Date(2020, 5, 12, 11, 45, 58, 0, time.UTC)},
+		{value: "101209113020X", err: true},
+		{value: "101309113020W", err: true},
+		{value: "", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			t.Parallel()
+
+			timestamp, err := TelegramValue{Value: test.value}.Time()
+			if test.err {
+				require.ErrorIs(t, err, ErrInvalidValue)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, test.result.Equal(timestamp), timestamp)
+		})
+	}
+}
+
+func TestTelegramValueDuration(t *testing.T) {
+	t.Parallel()
+
+	d, err := TelegramValue{"0000000237", "s"}.Duration()
+	require.NoError(t, err)
+	assert.Equal(t, 237*time.Second, d)
+
+	d, err = TelegramValue{Value: "2147483647"}.Duration()
+	require.NoError(t, err)
+	assert.Equal(t, 2147483647*time.Second, d)
+
+	_, err = TelegramValue{"0000000237", "kWh"}.Duration()
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.ErrorIs(t, err, ErrUnexpectedUnit)
+
+	_, err = TelegramValue{"foo", "s"}.Duration()
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestTelegramValueText(t *testing.T) {
+	t.Parallel()
+
+	text, err := TelegramValue{Value: "4B384547303034303436333935353037"}.Text()
+	require.NoError(t, err)
+	assert.Equal(t, "K8EG004046395507", text)
+
+	_, err = TelegramValue{Value: "4B3"}.Text()
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestTelegramGetters(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("testdata/parser/output1")
+	require.NoError(t, err)
+
+	tgram, err := ParseTelegram(fixture)
+	require.NoError(t, err)
+
+	f, err := tgram.Float(OBISTypeElectricityDeliveredTariff1)
+	require.NoError(t, err)
+	assert.InDelta(t, 123456.789, f, 0.0001)
+
+	// the reading follows the time it was taken
+	f, err = tgram.Float(OBISTypeGasDelivered)
+	require.NoError(t, err)
+	assert.InDelta(t, 12785.123, f, 0.0001)
+
+	i, err := tgram.Int(OBISTypeElectricityTariffIndicator)
+	require.NoError(t, err)
+	assert.Equal(t, 2, i)
+
+	timestamp, err := tgram.Time(OBISTypeDateTimestamp)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2010, 12, 9, 10, 30, 20, 0, time.UTC).Equal(timestamp))
+
+	text, err := tgram.Text(OBISTypeEquipmentIdentifier)
+	require.NoError(t, err)
+	assert.Equal(t, "K8EG004046395507", text)
+
+	_, err = tgram.Float(OBISTypeWaterDelivered)
+	require.ErrorIs(t, err, ErrObjectNotFound)
+	_, err = tgram.Int(OBISTypeWaterDelivered)
+	require.ErrorIs(t, err, ErrObjectNotFound)
+	_, err = tgram.Time(OBISTypeWaterDelivered)
+	require.ErrorIs(t, err, ErrObjectNotFound)
+	_, err = tgram.Text(OBISTypeWaterDelivered)
+	require.ErrorIs(t, err, ErrObjectNotFound)
+
+	assert.Nil(t, tgram.Object(OBISTypeWaterDelivered))
+	assert.Equal(t, "0-0:96.14.0", tgram.Object(OBISTypeElectricityTariffIndicator).Code.String())
+}